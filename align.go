@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// whisperxAligner is the Python package used for forced alignment. It only
+// needs Module and Package for the dependency check.
+var whisperxAligner = Backend{Name: "whisperx", Module: "whisperx", Package: "whisperx"}
+
+// scriptSegments spreads the words of a known script over the recognized
+// segments, so each piece of the script gets a rough time range to align
+// within. Words are matched with diffWords; script words that differ from
+// the recognized ones take the segments of the words they replace.
+func scriptSegments(recognized []Segment, script []string) []Segment {
+	var words []string
+	var owners []int // segment index of each recognized word
+	for i, seg := range recognized {
+		for _, word := range strings.Fields(seg.Text) {
+			words = append(words, word)
+			owners = append(owners, i)
+		}
+	}
+	if len(recognized) == 0 {
+		return nil
+	}
+
+	assigned := make([][]string, len(recognized))
+	a, b, current := 0, 0, 0
+	var deleted []int     // owners of the recognized words in the current changed run
+	var inserted []string // script words in the current changed run
+
+	// Spread a changed run's script words evenly over the segments of the
+	// recognized words they replace
+	flush := func() {
+		for k, word := range inserted {
+			owner := current
+			if len(deleted) > 0 {
+				owner = deleted[k*len(deleted)/len(inserted)]
+			}
+			assigned[owner] = append(assigned[owner], word)
+		}
+		if len(deleted) > 0 {
+			current = deleted[len(deleted)-1]
+		}
+		deleted, inserted = nil, nil
+	}
+
+	for _, chunk := range diffWords(words, script) {
+		switch chunk.Op {
+		case diffEqual:
+			flush()
+			for range chunk.Words {
+				current = owners[a]
+				assigned[current] = append(assigned[current], script[b])
+				a++
+				b++
+			}
+		case diffDelete:
+			deleted = append(deleted, owners[a:a+len(chunk.Words)]...)
+			a += len(chunk.Words)
+		case diffInsert:
+			inserted = append(inserted, script[b:b+len(chunk.Words)]...)
+			b += len(chunk.Words)
+		}
+	}
+	flush()
+
+	var segments []Segment
+	for i, seg := range recognized {
+		if len(assigned[i]) == 0 {
+			continue
+		}
+		segments = append(segments, Segment{Start: seg.Start, End: seg.End, Text: strings.Join(assigned[i], " ")})
+	}
+	return segments
+}
+
+// alignScript runs whisperX's phoneme-level alignment on the segments and
+// returns them with precise segment and word timings
+func (p *AudioProcessor) alignScript(ctx context.Context, language string, segments []Segment) ([]Segment, error) {
+	audioPath := filepath.Join(p.TempDir, "audio.wav")
+	if err := p.extractAudio(ctx, audioPath); err != nil {
+		return nil, fmt.Errorf("audio extraction failed: %w", err)
+	}
+
+	segmentsPath := filepath.Join(p.TempDir, "segments.json")
+	data, err := json.Marshal(segments)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(segmentsPath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	alignedPath := filepath.Join(p.TempDir, "aligned.json")
+	scriptPath := filepath.Join(p.TempDir, "align.py")
+	script := alignmentScript(audioPath, segmentsPath, alignedPath, language)
+	if err := os.WriteFile(scriptPath, []byte(script), 0644); err != nil {
+		return nil, err
+	}
+
+	output, err := exec.CommandContext(ctx, p.PythonPath, scriptPath).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("python alignment error: %s", output)
+	}
+
+	data, err = os.ReadFile(alignedPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alignment: %w", err)
+	}
+	var aligned []Segment
+	if err := json.Unmarshal(data, &aligned); err != nil {
+		return nil, fmt.Errorf("failed to parse alignment: %w", err)
+	}
+	return aligned, nil
+}
+
+// alignmentScript builds the whisperX alignment script. Audio is read from
+// the extracted 16 kHz WAV file so whisperX doesn't need ffmpeg in PATH.
+func alignmentScript(audioPath, segmentsPath, outputPath, language string) string {
+	return fmt.Sprintf(`
+import json
+import wave
+
+import numpy as np
+import torch
+import whisperx
+
+device = "cuda" if torch.cuda.is_available() else "cpu"
+
+with wave.open(%s, "rb") as f:
+    audio = np.frombuffer(f.readframes(f.getnframes()), np.int16).astype(np.float32) / 32768.0
+with open(%s, encoding="utf-8") as f:
+    segments = json.load(f)
+
+print("Loading alignment model...")
+model, metadata = whisperx.load_align_model(language_code=%q, device=device)
+print("Aligning transcript...")
+result = whisperx.align(segments, model, metadata, audio, device, return_char_alignments=False)
+
+# Words the model can't align (such as numerals) have no timing; they take
+# the end of the word before them
+aligned = []
+for s in result["segments"]:
+    last = s.get("start", 0.0)
+    words = []
+    for w in s.get("words", []):
+        start = w.get("start", last)
+        end = w.get("end", start)
+        words.append({"start": start, "end": end, "text": w["word"], "probability": w.get("score", 0.0)})
+        last = end
+    aligned.append({"start": s["start"], "end": s["end"], "text": s["text"].strip(), "words": words})
+
+with open(%s, "w", encoding="utf-8") as f:
+    json.dump(aligned, f, ensure_ascii=False)
+`, pythonPath(audioPath), pythonPath(segmentsPath), language, pythonPath(outputPath))
+}
+
+// runAlign times a known script against its recording: a normal
+// transcription finds roughly where each part of the script is spoken,
+// then whisperX aligns the script's words precisely
+func runAlign(args []string) error {
+	fs := flag.NewFlagSet("align", flag.ContinueOnError)
+	textPath := fs.String("text", "", "transcript or script to align (required)")
+	outputDir := fs.String("output-dir", "", "directory to write the timed transcript to")
+	cfg, err := addConfigFlags(fs)
+	if err != nil {
+		return err
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *textPath == "" || fs.NArg() != 1 {
+		return fmt.Errorf("usage: stt-cli align --text SCRIPT [flags] FILE")
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	path := fs.Arg(0)
+	if isRemoteInput(path) {
+		return fmt.Errorf("align needs a local audio file")
+	}
+	data, err := os.ReadFile(*textPath)
+	if err != nil {
+		return fmt.Errorf("failed to read script: %w", err)
+	}
+	script := strings.Fields(string(data))
+	if len(script) == 0 {
+		return fmt.Errorf("%s is empty", *textPath)
+	}
+
+	fmt.Println("Transcribing to locate the script in the audio...")
+	recognized, err := processAudioSTT(path, *cfg)
+	if err != nil {
+		return err
+	}
+
+	// A low score usually means the wrong script or the wrong language
+	agreement := wordAgreement(diffWords(strings.Fields(recognized.Text), script))
+	fmt.Printf("The script and the recognized speech share %.0f%% of their words\n", agreement*100)
+	if agreement < 0.5 {
+		fmt.Println("Warning: the script and the recording differ a lot; timings may be poor")
+	}
+
+	language := recognized.Language
+	if language == "" && cfg.Language != "auto" {
+		language = cfg.Language
+	}
+	if language == "" {
+		return fmt.Errorf("could not detect the language; pass --language")
+	}
+
+	tempDir, err := os.MkdirTemp("", "audio_stt-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	ctx := context.Background()
+	processor := &AudioProcessor{InputPath: path, TempDir: tempDir, Config: *cfg, Backend: whisperxAligner}
+	if err := processor.checkDependencies(ctx); err != nil {
+		return fmt.Errorf("dependency check failed: %w", err)
+	}
+	fmt.Println("Aligning the script with whisperX...")
+	segments, err := processor.alignScript(ctx, language, scriptSegments(recognized.Segments, script))
+	if err != nil {
+		return fmt.Errorf("alignment failed: %w", err)
+	}
+
+	aligned := &Transcript{
+		Text:     strings.Join(script, " "),
+		Language: language,
+		Segments: segments,
+		Source:   path,
+		Model:    recognized.Model,
+		Backend:  whisperxAligner.Name,
+		Duration: recognized.Duration,
+	}
+	saved, err := writeTranscripts(aligned, transcriptPath(path, *outputDir, ""), *cfg)
+	for _, outputPath := range saved {
+		fmt.Printf("Saved %s\n", outputPath)
+	}
+	return err
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Analysis is the tone and vocabulary of a transcript, filled in when
+// Config.Analyze is set
+type Analysis struct {
+	// Sentiment is the tone of the whole transcript from -1 (negative) to
+	// 1 (positive), averaged over the segments by their length. It is only
+	// measured for English.
+	Sentiment *float64 `json:"sentiment,omitempty"`
+
+	Keywords []string `json:"keywords"`
+	Topics   []string `json:"topics"`
+}
+
+const (
+	// analysisKeywords and analysisTopics are how many of each are kept
+	analysisKeywords = 10
+	analysisTopics   = 5
+
+	// neutralTone is the sentiment below which a segment counts as neutral
+	neutralTone = 0.05
+
+	// negationReach is how many words after "not" have their tone flipped
+	negationReach = 3
+)
+
+// sentimentWords scores English words from -3 to 3, after the lexicons
+// used for customer conversations
+var sentimentWords = map[string]float64{
+	"amazing": 3, "awesome": 3, "excellent": 3, "fantastic": 3, "perfect": 3, "wonderful": 3, "love": 3,
+	"great": 2, "happy": 2, "glad": 2, "pleased": 2, "appreciate": 2, "thanks": 2, "thank": 2,
+	"helpful": 2, "resolved": 2, "fixed": 2, "recommend": 2, "excited": 2, "enjoy": 2,
+	"good": 1.5, "nice": 1.5, "easy": 1.5, "works": 1, "fine": 1, "sure": 0.5, "okay": 0.5, "better": 1,
+	"terrible": -3, "horrible": -3, "awful": -3, "worst": -3, "hate": -3, "unacceptable": -3, "furious": -3,
+	"angry": -2, "frustrated": -2, "frustrating": -2, "disappointed": -2, "annoyed": -2, "upset": -2,
+	"broken": -2, "useless": -2, "ridiculous": -2, "complaint": -2, "refund": -1.5, "cancel": -1.5,
+	"bad": -1.5, "wrong": -1.5, "problem": -1.5, "problems": -1.5, "issue": -1, "issues": -1,
+	"failed": -1.5, "error": -1, "slow": -1, "late": -1, "confusing": -1.5, "worse": -1.5, "sorry": -0.5,
+}
+
+// negations flip the tone of the words that follow them
+var negations = map[string]bool{
+	"not": true, "no": true, "never": true, "don't": true, "doesn't": true, "didn't": true,
+	"isn't": true, "wasn't": true, "aren't": true, "can't": true, "cannot": true, "won't": true,
+}
+
+// intensifiers strengthen the next word
+var intensifiers = map[string]float64{
+	"very": 1.5, "really": 1.5, "so": 1.3, "extremely": 2, "totally": 1.5, "completely": 1.5,
+}
+
+// textSentiment scores the tone of text from -1 to 1. Word scores are
+// summed and squashed, so longer emotional text leans further.
+func textSentiment(text string) float64 {
+	sum := 0.0
+	negated := 0
+	boost := 1.0
+	for _, field := range strings.Fields(text) {
+		word := strings.ToLower(strings.Trim(field, `.,!?;:"()`))
+		if negations[word] {
+			negated = negationReach
+			continue
+		}
+		if factor, ok := intensifiers[word]; ok {
+			boost = factor
+			continue
+		}
+		if score, ok := sentimentWords[word]; ok {
+			if negated > 0 {
+				score = -score / 2 // "not great" is milder than "bad"
+			}
+			sum += score * boost
+		}
+		boost = 1
+		if negated > 0 {
+			negated--
+		}
+	}
+	return sum / math.Sqrt(sum*sum+15)
+}
+
+// topicPhrases returns the n most frequent pairs of adjacent content words
+// that occur more than once, such as "credit card"
+func topicPhrases(segments []Segment, n int) []string {
+	counts := map[string]int{}
+	for _, seg := range segments {
+		words := normalizedWords(seg.Text)
+		for i := 1; i < len(words); i++ {
+			a, b := words[i-1], words[i]
+			if len(a) < 3 || len(b) < 3 || stopwords[a] || stopwords[b] {
+				continue
+			}
+			counts[a+" "+b]++
+		}
+	}
+
+	var phrases []string
+	for phrase, count := range counts {
+		if count > 1 {
+			phrases = append(phrases, phrase)
+		}
+	}
+	sort.Slice(phrases, func(i, j int) bool {
+		if counts[phrases[i]] != counts[phrases[j]] {
+			return counts[phrases[i]] > counts[phrases[j]]
+		}
+		return phrases[i] < phrases[j]
+	})
+	if len(phrases) > n {
+		phrases = phrases[:n]
+	}
+	return phrases
+}
+
+// analyzeTranscript scores the tone of each segment and finds the
+// transcript's keywords and topics. The word lists are English, so tone is
+// left out for other languages.
+func analyzeTranscript(t *Transcript, cfg Config) {
+	analysis := &Analysis{
+		Keywords: topKeywords(t.Text, analysisKeywords),
+		Topics:   topicPhrases(t.Segments, analysisTopics),
+	}
+
+	if t.Language == "en" || cfg.Translate {
+		var weighted, total float64
+		for i, seg := range t.Segments {
+			score := textSentiment(seg.Text)
+			t.Segments[i].Sentiment = &score
+			length := seg.End - seg.Start
+			weighted += score * length
+			total += length
+		}
+		if total > 0 {
+			overall := weighted / total
+			analysis.Sentiment = &overall
+		}
+	}
+	t.Analysis = analysis
+}
+
+// toneLabel describes a sentiment score in a word
+func toneLabel(score float64) string {
+	switch {
+	case score >= neutralTone:
+		return "positive"
+	case score <= -neutralTone:
+		return "negative"
+	}
+	return "neutral"
+}
+
+// toneStyle colors text by the tone of a score
+func toneStyle(score float64, text string) string {
+	switch toneLabel(score) {
+	case "positive":
+		return successStyle.Render(text)
+	case "negative":
+		return errorStyle.Render(text)
+	}
+	return subtitleStyle.Render(text)
+}
+
+// toneTimeline draws the tone over the recording in width columns: the bar
+// height is how strong the tone is, its color whether it is positive
+func toneTimeline(segments []Segment, width int) string {
+	if len(segments) == 0 || width <= 0 {
+		return ""
+	}
+	end := segments[len(segments)-1].End
+	ramp := []rune(waveformRamp)
+
+	var b strings.Builder
+	for col := 0; col < width; col++ {
+		from := end * float64(col) / float64(width)
+		to := end * float64(col+1) / float64(width)
+
+		var weighted, total float64
+		for _, seg := range segments {
+			overlap := math.Min(seg.End, to) - math.Max(seg.Start, from)
+			if seg.Sentiment == nil || overlap <= 0 {
+				continue
+			}
+			weighted += *seg.Sentiment * overlap
+			total += overlap
+		}
+		score := 0.0
+		if total > 0 {
+			score = weighted / total
+		}
+		level := min(len(ramp)-1, int(math.Abs(score)*float64(len(ramp))))
+		b.WriteString(toneStyle(score, string(ramp[level])))
+	}
+	return b.String()
+}
+
+// analysisLines renders the analysis panel of the results screen: the
+// tone over time with the selected segment's, then keywords and topics
+func (m model) analysisLines() []string {
+	if m.transcript == nil || m.transcript.Analysis == nil {
+		return nil
+	}
+	analysis := m.transcript.Analysis
+	width := m.width - 8
+
+	var lines []string
+	if s := analysis.Sentiment; s != nil {
+		line := tr("Tone: %s (%+.2f)  ", toneStyle(*s, tr(toneLabel(*s))), *s)
+		selected := ""
+		if m.selectedSegment < len(m.transcript.Segments) {
+			if seg := m.transcript.Segments[m.selectedSegment]; seg.Sentiment != nil {
+				selected = tr("  selected: %s (%+.2f)", tr(toneLabel(*seg.Sentiment)), *seg.Sentiment)
+			}
+		}
+		timeline := toneTimeline(m.transcript.Segments, max(10, min(40, width-len(selected)-30)))
+		lines = append(lines, line+timeline+subtitleStyle.Render(selected))
+	}
+	if len(analysis.Keywords) > 0 {
+		lines = append(lines, truncate(tr("Keywords: ")+strings.Join(analysis.Keywords, ", "), width))
+	}
+	if len(analysis.Topics) > 0 {
+		lines = append(lines, truncate(tr("Topics: ")+strings.Join(analysis.Topics, ", "), width))
+	}
+	return lines
+}
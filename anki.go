@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// clipPadding is added around each segment so cards don't cut off speech
+const clipPadding = 0.15
+
+// ankiDeckPath returns where the cards for a transcript are written; the
+// clips go in a folder next to it
+func ankiDeckPath(base string) (deck, media string) {
+	return base + ".anki.tsv", base + ".anki-media"
+}
+
+// ankiField flattens text into a single tab-separated field
+func ankiField(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// writeAnkiDeck writes one flashcard per segment: a tab-separated file Anki
+// can import, with the segment text, an audio clip of it cut from the
+// source and its time, plus a folder with the clips
+func writeAnkiDeck(t *Transcript, base string) ([]string, error) {
+	if t.Source == "" || isRemoteInput(t.Source) {
+		return nil, fmt.Errorf("flashcards need the original media file to cut clips from")
+	}
+	if _, err := os.Stat(t.Source); err != nil {
+		return nil, fmt.Errorf("flashcards need the original media file: %w", err)
+	}
+	ffmpegPath, err := findFFmpeg()
+	if err != nil {
+		return nil, err
+	}
+
+	deckPath, mediaDir := ankiDeckPath(base)
+	if err := os.MkdirAll(mediaDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create media folder: %w", err)
+	}
+
+	// Anki keeps all media in one folder, so clip names carry the file name
+	prefix := strings.ReplaceAll(filepath.Base(base), " ", "_")
+	source := filepath.Base(t.Source)
+
+	var b strings.Builder
+	b.WriteString("#separator:tab\n#html:false\n#columns:Text\tAudio\tTime\tSource\n")
+	for i, seg := range t.Segments {
+		text := ankiField(seg.Text)
+		if text == "" {
+			continue
+		}
+
+		clip := fmt.Sprintf("%s-%04d.mp3", prefix, i+1)
+		clipFile := filepath.Join(mediaDir, clip)
+		err := cutClip(ffmpegPath, t.Source, seg.Start-clipPadding, seg.End+clipPadding, clipFile,
+			"-ac", "1", "-codec:a", "libmp3lame", "-q:a", "5")
+		if err != nil {
+			return nil, fmt.Errorf("failed to cut clip for segment %d: %w", i+1, err)
+		}
+
+		fmt.Fprintf(&b, "%s\t[sound:%s]\t%s\t%s\n", text, clip, formatTimestamp(seg.Start, ".")[:8], ankiField(source))
+	}
+
+	if err := os.WriteFile(deckPath, []byte(b.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write flashcards: %w", err)
+	}
+	return []string{deckPath, mediaDir}, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// errUnauthenticated is returned for requests without a valid token
+var errUnauthenticated = errors.New("missing or invalid bearer token")
+
+// authenticator checks the bearer tokens sent to the server. Tokens are
+// accepted if they are in the static list or are valid OIDC ID tokens.
+type authenticator struct {
+	tokens   []string
+	verifier *oidc.IDTokenVerifier
+}
+
+// newAuthenticator reads the static tokens and discovers the OIDC issuer.
+// It returns nil when neither is configured, which leaves the APIs open.
+func newAuthenticator(ctx context.Context, tokensFile, issuer, audience string) (*authenticator, error) {
+	if tokensFile == "" && issuer == "" {
+		return nil, nil
+	}
+
+	auth := &authenticator{}
+	if tokensFile != "" {
+		tokens, err := readTokens(tokensFile)
+		if err != nil {
+			return nil, err
+		}
+		auth.tokens = tokens
+	}
+
+	if issuer != "" {
+		if audience == "" {
+			return nil, fmt.Errorf("--oidc-audience is required with --oidc-issuer")
+		}
+		provider, err := oidc.NewProvider(ctx, issuer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover OIDC issuer %s: %w", issuer, err)
+		}
+		auth.verifier = provider.Verifier(&oidc.Config{ClientID: audience})
+	}
+
+	return auth, nil
+}
+
+// readTokens reads one token per line, skipping blank lines and # comments
+func readTokens(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens: %w", err)
+	}
+	defer file.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tokens: %w", err)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no tokens in %s", path)
+	}
+	return tokens, nil
+}
+
+// check validates an Authorization header value
+func (a *authenticator) check(ctx context.Context, header string) error {
+	const prefix = "Bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return errUnauthenticated
+	}
+	token := strings.TrimSpace(header[len(prefix):])
+
+	for _, known := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+			return nil
+		}
+	}
+	if a.verifier != nil {
+		if _, err := a.verifier.Verify(ctx, token); err == nil {
+			return nil
+		}
+	}
+	return errUnauthenticated
+}
+
+// middleware rejects HTTP requests without a valid token
+func (a *authenticator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := a.check(r.Context(), r.Header.Get("Authorization")); err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="stt-cli"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// streamInterceptor rejects gRPC calls without a valid token in the
+// "authorization" metadata
+func (a *authenticator) streamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	var header string
+	if md, ok := metadata.FromIncomingContext(stream.Context()); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			header = values[0]
+		}
+	}
+	if err := a.check(stream.Context(), header); err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	return handler(srv, stream)
+}
+
+// isLoopback reports whether a listen address only accepts local connections
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Backend describes a local speech recognition engine driven through Python
+type Backend struct {
+	Name    string
+	Module  string // Python module to import
+	Package string // pip package providing the module
+	Script  func(audioPath, outputPath string, cfg Config) string
+
+	// DetectScript identifies the language of each window listed in a JSON
+	// file of languageSamples, writing them back with languages filled in
+	DetectScript func(audioPath, windowsPath, outputPath string, cfg Config) string
+
+	// Boost is how Script passes the boost phrases to the engine
+	Boost BoostMethod
+}
+
+// BoostMethod is a backend's mechanism for favouring given phrases
+type BoostMethod int
+
+const (
+	// BoostNone means the backend can't be given phrases
+	BoostNone BoostMethod = iota
+
+	// BoostPrompt writes the phrases into initial_prompt, which Whisper
+	// reads as text spoken just before the audio. It works with any model
+	// but only the last 224 tokens of it are used.
+	BoostPrompt
+
+	// BoostHotwords passes the phrases as faster-whisper's hotwords
+	BoostHotwords
+)
+
+// String names the mechanism as shown by --dry-run
+func (b BoostMethod) String() string {
+	switch b {
+	case BoostPrompt:
+		return "initial prompt"
+	case BoostHotwords:
+		return "hotwords"
+	}
+	return "not supported"
+}
+
+// backends lists the supported transcription engines
+var backends = []Backend{
+	{
+		Name:    "whisper",
+		Module:  "whisper",
+		Package: "openai-whisper",
+		Script:  whisperScript,
+
+		DetectScript: whisperDetectScript,
+		Boost:        BoostPrompt,
+	},
+	{
+		Name:    "faster-whisper",
+		Module:  "faster_whisper",
+		Package: "faster-whisper",
+		Script:  fasterWhisperScript,
+
+		DetectScript: fasterWhisperDetectScript,
+		Boost:        BoostHotwords,
+	},
+}
+
+// backendNames returns the names of all supported backends
+func backendNames() []string {
+	names := make([]string, len(backends))
+	for i, b := range backends {
+		names[i] = b.Name
+	}
+	return names
+}
+
+// findBackend looks up one of the backends users can choose
+func findBackend(name string) (Backend, error) {
+	for _, b := range backends {
+		if b.Name == name {
+			return b, nil
+		}
+	}
+	return Backend{}, fmt.Errorf("unknown backend %q (available: %s)", name, strings.Join(backendNames(), ", "))
+}
+
+// pipelineBackend looks up the backend a run uses, which may also be the
+// fake one that the demo and the integration tests set up themselves.
+// Settings from users and clients are checked with findBackend, so they
+// can't ask for it.
+func pipelineBackend(name string) (Backend, error) {
+	if name == fakeBackend.Name {
+		return fakeBackend, nil
+	}
+	return findBackend(name)
+}
+
+// pythonLanguage returns the Python literal for the language setting,
+// where "auto" lets the model detect the language
+func pythonLanguage(language string) string {
+	if language == "" || language == "auto" {
+		return "None"
+	}
+	return fmt.Sprintf("%q", language)
+}
+
+// segmentLinePrefix marks the lines scripts print for each finished
+// segment, so results can be streamed before the whole file is done
+const segmentLinePrefix = "STT-SEGMENT "
+
+// decodeOptions returns extra keyword arguments for model.transcribe, each
+// prefixed with a comma, with the boost phrases passed the way given
+func decodeOptions(cfg Config, boost BoostMethod) string {
+	var options []string
+	if cfg.Translate {
+		options = append(options, `task="translate"`)
+	}
+	if len(cfg.BoostPhrases) > 0 {
+		switch boost {
+		case BoostPrompt:
+			options = append(options, fmt.Sprintf("initial_prompt=%q", boostPrompt(cfg.BoostPhrases)))
+		case BoostHotwords:
+			options = append(options, fmt.Sprintf("hotwords=%q", strings.Join(cfg.BoostPhrases, ", ")))
+		}
+	}
+
+	if len(options) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(options, ", ")
+}
+
+// boostPrompt writes the phrases as a sentence, since Whisper expects its
+// prompt to read like a previous stretch of transcript
+func boostPrompt(phrases []string) string {
+	return strings.Join(phrases, ", ") + "."
+}
+
+// whisperScript builds the script for the openai-whisper package
+func whisperScript(audioPath, outputPath string, cfg Config) string {
+	if cfg.LowMemory {
+		return lowMemoryScript(audioPath, outputPath, cfg, fmt.Sprintf(`
+import whisper
+
+print("Loading Whisper model...")
+model = whisper.load_model(%q)
+`, cfg.Model), fmt.Sprintf(`
+def decode(audio, language):
+    result = model.transcribe(audio, language=language, word_timestamps=True%s)
+    return result.get("language", ""), [
+        {
+            "start": s["start"],
+            "end": s["end"],
+            "text": s["text"].strip(),
+            "words": [
+                {"start": w["start"], "end": w["end"], "text": w["word"].strip(), "probability": w["probability"]}
+                for w in s.get("words", [])
+            ],
+        }
+        for s in result["segments"]
+    ]
+`, decodeOptions(cfg, BoostPrompt)))
+	}
+	return fmt.Sprintf(`
+import json
+import whisper
+
+print("Loading Whisper model...")
+model = whisper.load_model(%q)
+print("Transcribing audio...")
+result = model.transcribe(%s, language=%s, word_timestamps=True%s)
+
+segments = [
+    {
+        "start": s["start"],
+        "end": s["end"],
+        "text": s["text"].strip(),
+        "words": [
+            {"start": w["start"], "end": w["end"], "text": w["word"].strip(), "probability": w["probability"]}
+            for w in s.get("words", [])
+        ],
+    }
+    for s in result["segments"]
+]
+for segment in segments:
+    print(%q + json.dumps(segment), flush=True)
+
+output = {
+    "text": result["text"].strip(),
+    "language": result.get("language", ""),
+    "segments": segments,
+}
+print("Transcription completed")
+
+# Save to a temporary file for Go to read
+with open(%s, "w", encoding="utf-8") as f:
+    json.dump(output, f, ensure_ascii=False)
+`, cfg.Model, pythonPath(audioPath), pythonLanguage(cfg.Language), decodeOptions(cfg, BoostPrompt), segmentLinePrefix, pythonPath(outputPath))
+}
+
+// fasterWhisperScript builds the script for the faster-whisper package
+func fasterWhisperScript(audioPath, outputPath string, cfg Config) string {
+	if cfg.LowMemory {
+		// 8-bit weights take a quarter of the memory of 32-bit ones
+		return lowMemoryScript(audioPath, outputPath, cfg, fmt.Sprintf(`
+from faster_whisper import WhisperModel
+
+print("Loading faster-whisper model...")
+model = WhisperModel(%q, device="auto", compute_type="int8")
+`, cfg.Model), fmt.Sprintf(`
+def decode(audio, language):
+    result, info = model.transcribe(audio, language=language, word_timestamps=True%s)
+    return info.language, (
+        {
+            "start": s.start,
+            "end": s.end,
+            "text": s.text.strip(),
+            "words": [
+                {"start": w.start, "end": w.end, "text": w.word.strip(), "probability": w.probability}
+                for w in (s.words or [])
+            ],
+        }
+        for s in result
+    )
+`, decodeOptions(cfg, BoostHotwords)))
+	}
+	return fmt.Sprintf(`
+import json
+from faster_whisper import WhisperModel
+
+print("Loading faster-whisper model...")
+model = WhisperModel(%q, device="auto", compute_type="default")
+print("Transcribing audio...")
+result, info = model.transcribe(%s, language=%s, word_timestamps=True%s)
+
+# Segments are generated lazily, so each one is reported as it is decoded
+segments = []
+for s in result:
+    segment = {
+        "start": s.start,
+        "end": s.end,
+        "text": s.text.strip(),
+        "words": [
+            {"start": w.start, "end": w.end, "text": w.word.strip(), "probability": w.probability}
+            for w in (s.words or [])
+        ],
+    }
+    segments.append(segment)
+    print(%q + json.dumps(segment), flush=True)
+
+output = {
+    "text": " ".join(s["text"] for s in segments).strip(),
+    "language": info.language,
+    "segments": segments,
+}
+print("Transcription completed")
+
+# Save to a temporary file for Go to read
+with open(%s, "w", encoding="utf-8") as f:
+    json.dump(output, f, ensure_ascii=False)
+`, cfg.Model, pythonPath(audioPath), pythonLanguage(cfg.Language), decodeOptions(cfg, BoostHotwords), segmentLinePrefix, pythonPath(outputPath))
+}
+
+// detectScriptSetup loads the extracted audio and the windows to examine;
+// both detection scripts start with it
+const detectScriptSetup = `
+import json
+import wave
+
+import numpy as np
+
+with wave.open(%s, "rb") as f:
+    audio = np.frombuffer(f.readframes(f.getnframes()), np.int16).astype(np.float32) / 32768.0
+with open(%s, encoding="utf-8") as f:
+    windows = json.load(f)
+`
+
+// whisperDetectScript builds the language detection script for openai-whisper
+func whisperDetectScript(audioPath, windowsPath, outputPath string, cfg Config) string {
+	return fmt.Sprintf(detectScriptSetup, pythonPath(audioPath), pythonPath(windowsPath)) + fmt.Sprintf(`
+import whisper
+
+model = whisper.load_model(%q)
+for w in windows:
+    clip = whisper.pad_or_trim(audio[int(w["start"] * 16000):int(w["end"] * 16000)])
+    mel = whisper.log_mel_spectrogram(clip, n_mels=model.dims.n_mels).to(model.device)
+    _, probs = model.detect_language(mel)
+    w["language"] = max(probs, key=probs.get)
+    w["probability"] = probs[w["language"]]
+
+with open(%s, "w", encoding="utf-8") as f:
+    json.dump(windows, f)
+`, cfg.Model, pythonPath(outputPath))
+}
+
+// fasterWhisperDetectScript builds the language detection script for
+// faster-whisper. transcribe detects the language up front; its segments
+// are generated lazily, so nothing else is decoded.
+func fasterWhisperDetectScript(audioPath, windowsPath, outputPath string, cfg Config) string {
+	return fmt.Sprintf(detectScriptSetup, pythonPath(audioPath), pythonPath(windowsPath)) + fmt.Sprintf(`
+from faster_whisper import WhisperModel
+
+model = WhisperModel(%q, device="auto", compute_type="default")
+for w in windows:
+    _, info = model.transcribe(audio[int(w["start"] * 16000):int(w["end"] * 16000)])
+    w["language"] = info.language
+    w["probability"] = info.language_probability
+
+with open(%s, "w", encoding="utf-8") as f:
+    json.dump(windows, f)
+`, cfg.Model, pythonPath(outputPath))
+}
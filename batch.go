@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// batchOptions are the transcribe flags that apply to every file of a batch
+type batchOptions struct {
+	Config         Config
+	OutputDir      string
+	Output         string // single file to write instead, or "-" for stdout
+	DetectLanguage bool
+	DetectOnly     bool
+	KeepPartial    bool // save the finished segments of files that fail
+	Manifest       bool // write a reproducibility manifest next to each transcript
+	Merge          bool // keep the transcripts to merge instead of saving them
+}
+
+// statusOutput is where progress and reports go: standard output, unless
+// the transcript itself is written there
+func (o batchOptions) statusOutput() *os.File {
+	if o.Output == "-" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// save writes a transcript where the options say: the files under base,
+// or the single --output. It returns the files written.
+func (o batchOptions) save(t *Transcript, base string, cfg Config) ([]string, error) {
+	switch o.Output {
+	case "":
+		return writeTranscripts(t, base, cfg)
+	case "-":
+		return nil, writeTranscriptOutput(t, o.Output, cfg)
+	}
+	if err := writeTranscriptOutput(t, o.Output, cfg); err != nil {
+		return nil, err
+	}
+	return []string{o.Output}, nil
+}
+
+// batchFile is the state of one file of a batch
+type batchFile struct {
+	Path     string
+	Stage    string
+	Duration float64   // seconds of audio, 0 until the file is probed
+	Done     float64   // seconds transcribed so far
+	Started  time.Time // when the file started, for the ETA
+	Finished bool
+	Err      error
+	Report   []string    // languages heard and files saved
+	Result   *Transcript // kept when merging
+}
+
+// fraction returns how much of the audio has been transcribed, or -1 when
+// the length of the audio isn't known
+func (f batchFile) fraction() float64 {
+	if f.Duration <= 0 {
+		return -1
+	}
+	return math.Min(1, f.Done/f.Duration)
+}
+
+// eta estimates the time left from the pace so far, or 0 before there is
+// a pace to go by
+func (f batchFile) eta() time.Duration {
+	fraction := f.fraction()
+	if fraction <= 0 || f.Started.IsZero() {
+		return 0
+	}
+	elapsed := time.Since(f.Started)
+	return time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+}
+
+// transcribeBatchFile detects the languages of one file if asked, then
+// transcribes it and saves the transcripts, passing a copy of the file's
+// state to update whenever it changes
+func transcribeBatchFile(ctx context.Context, path string, opts batchOptions, store *jobStore, update func(batchFile)) batchFile {
+	file := batchFile{Path: path, Stage: "Starting", Started: time.Now()}
+	update(file)
+	fail := func(err error) batchFile {
+		file.Finished, file.Err, file.Stage = true, err, "Failed"
+		update(file)
+		return file
+	}
+
+	cfg := opts.Config
+	if opts.DetectLanguage || opts.DetectOnly {
+		file.Stage = "Detecting languages"
+		update(file)
+		samples, err := detectFileLanguages(ctx, path, cfg, detectionSamples)
+		if err != nil {
+			return fail(fmt.Errorf("language detection failed: %w", err))
+		}
+		file.Report = append(file.Report, strings.Split(strings.TrimRight(languageReport(samples), "\n"), "\n")...)
+		if opts.DetectOnly {
+			file.Finished, file.Stage = true, "Done"
+			update(file)
+			return file
+		}
+
+		// A clear majority is more reliable than Whisper's guess from the
+		// first 30 seconds
+		if language, ok := dominantLanguage(samples); ok && cfg.Language == "auto" {
+			cfg.Language = language
+			file.Report = append(file.Report, "  transcribing as "+language)
+		}
+		if len(countLanguages(samples)) > 1 && !cfg.CodeSwitching {
+			file.Report = append(file.Report, "  several languages found; --code-switching tags each segment with its language")
+		}
+	}
+
+	job := &jobRecord{Mode: "transcribe", Input: path, Config: cfg}
+	if !isRemoteInput(path) {
+		if abs, err := filepath.Abs(path); err == nil {
+			job.Input = abs
+		}
+	}
+	if err := store.create(job); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	store.start(job)
+
+	var manifest *runManifest
+	if opts.Manifest {
+		manifest = newRunManifest(path, cfg, file.Started)
+	}
+	var segments []Segment
+	transcript, stats, err := runPipeline(ctx, path, cfg, PipelineHooks{
+		Progress: func(stage string) {
+			file.Stage = stage
+			update(file)
+		},
+		Media: func(info *MediaInfo) error {
+			if info != nil {
+				file.Duration = info.Duration
+			}
+			if manifest != nil {
+				manifest.setMedia(info)
+			}
+			return nil
+		},
+		Segment: func(seg Segment) {
+			segments = append(segments, seg)
+			file.Done = seg.End
+			update(file)
+		},
+	})
+	var saved []string
+	base := transcriptPath(path, opts.OutputDir, "")
+	if err == nil && opts.Merge {
+		file.Result = transcript
+	} else if err == nil {
+		saved, err = opts.save(transcript, base, cfg)
+	} else if opts.KeepPartial && len(segments) > 0 {
+		partial := partialTranscript(segments, path, cfg)
+		transcript = partial
+		var saveErr error
+		saved, saveErr = opts.save(partial, base, cfg)
+		if saveErr != nil {
+			file.Report = append(file.Report, "  failed to keep the partial transcript: "+saveErr.Error())
+		} else {
+			file.Report = append(file.Report, fmt.Sprintf("  kept the segments up to %s", formatClock(partial.duration())))
+		}
+	}
+	store.finish(job, stats, saved, err)
+
+	if manifest != nil {
+		manifest.finish(transcript, stats, saved, err)
+		manifestPath := transcriptPath(path, opts.OutputDir, manifestExtension)
+		if opts.Output != "" && opts.Output != "-" {
+			manifestPath = opts.Output + manifestExtension
+		}
+		if writeErr := manifest.write(manifestPath); writeErr != nil {
+			file.Report = append(file.Report, "  "+writeErr.Error())
+		} else {
+			file.Report = append(file.Report, "  saved "+manifestPath)
+		}
+	}
+
+	for _, outputPath := range saved {
+		file.Report = append(file.Report, "  saved "+outputPath)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	file.Finished, file.Stage, file.Done = true, "Done", file.Duration
+	update(file)
+	return file
+}
+
+// runBatch transcribes paths with up to jobs files at a time, showing a
+// dashboard on terminals and a line per change otherwise, and returns the
+// final state of each file. Ctrl+C stops the files in progress.
+func runBatch(paths []string, jobs int, opts batchOptions, store *jobStore, dashboard bool) []batchFile {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	status := opts.statusOutput()
+	var program *tea.Program
+	var mu sync.Mutex
+	printed := make([]batchFile, len(paths))
+	update := func(index int, file batchFile) {
+		if program != nil {
+			program.Send(batchFileMsg{index, file})
+			return
+		}
+
+		// Without the dashboard, print what changed as it happens
+		mu.Lock()
+		defer mu.Unlock()
+		prefix := fmt.Sprintf("[%d/%d] ", index+1, len(paths))
+		if file.Stage != printed[index].Stage {
+			fmt.Fprintf(status, "%s%s: %s\n", prefix, file.Path, file.Stage)
+		}
+		for _, line := range file.Report[len(printed[index].Report):] {
+			fmt.Fprintln(status, prefix+line)
+		}
+		if file.Err != nil && printed[index].Err == nil {
+			fmt.Fprintf(status, "%s  failed: %v\n", prefix, file.Err)
+		}
+		printed[index] = file
+	}
+
+	results := make([]batchFile, len(paths))
+	run := func() {
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, jobs)
+		for i, path := range paths {
+			// Taking the slot before starting keeps files in order
+			slots <- struct{}{}
+			wg.Add(1)
+			go func(i int, path string) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				if ctx.Err() != nil {
+					results[i] = batchFile{Path: path, Stage: "Cancelled", Finished: true, Err: ctx.Err()}
+					update(i, results[i])
+					return
+				}
+				results[i] = transcribeBatchFile(ctx, path, opts, store, func(file batchFile) { update(i, file) })
+			}(i, path)
+		}
+		wg.Wait()
+	}
+
+	if dashboard {
+		files := make([]batchFile, len(paths))
+		for i, path := range paths {
+			files[i] = batchFile{Path: path, Stage: "Queued"}
+		}
+		s := spinner.New()
+		s.Spinner = spinnerType
+		program = tea.NewProgram(batchModel{files: files, jobs: jobs, spinner: s, cancel: cancel, width: 80}, tea.WithOutput(status))
+		done := make(chan struct{})
+		go func() {
+			run()
+			close(done)
+			program.Send(batchDoneMsg{})
+		}()
+		if _, err := program.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			cancel()
+		}
+		// The files still running write their results until they stop
+		<-done
+
+		// The reports didn't fit the dashboard
+		for i, file := range results {
+			if len(file.Report) == 0 && file.Err == nil {
+				continue
+			}
+			fmt.Fprintf(status, "[%d/%d] %s\n", i+1, len(paths), file.Path)
+			for _, line := range file.Report {
+				fmt.Fprintln(status, line)
+			}
+			if file.Err != nil {
+				fmt.Fprintf(status, "  failed: %v\n", file.Err)
+			}
+		}
+	} else {
+		run()
+	}
+
+	return results
+}
+
+// batchFileMsg carries the new state of one file to the dashboard
+type batchFileMsg struct {
+	index int
+	file  batchFile
+}
+
+// batchDoneMsg tells the dashboard that every file has finished
+type batchDoneMsg struct{}
+
+// batchModel is the dashboard of a batch: one row per file with its stage,
+// progress and time left
+type batchModel struct {
+	files      []batchFile
+	jobs       int
+	spinner    spinner.Model
+	cancel     context.CancelFunc
+	cancelling bool
+	width      int
+}
+
+func (m batchModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m batchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case batchFileMsg:
+		m.files[msg.index] = msg.file
+	case batchDoneMsg:
+		return m, tea.Quit
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.cancelling = true
+			m.cancel()
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// progressBar draws fraction as a bar of width cells
+func progressBar(fraction float64, width int) string {
+	ramp := []rune(waveformRamp)
+	filled := int(fraction * float64(width))
+	return strings.Repeat(string(ramp[len(ramp)-1]), filled) + strings.Repeat(string(ramp[0]), width-filled)
+}
+
+// formatETA renders the time left to the second, or to the minute when long
+func formatETA(d time.Duration) string {
+	if d >= 10*time.Minute {
+		return d.Round(time.Minute).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+func (m batchModel) View() string {
+	nameWidth := max(12, min(40, m.width-60))
+	done, failed := 0, 0
+
+	var rows []string
+	for _, file := range m.files {
+		icon := " "
+		switch {
+		case file.Err != nil:
+			icon = errorStyle.Render("x")
+			failed++
+		case file.Finished:
+			icon = successStyle.Render(doneMarker)
+			done++
+		case file.Stage != "Queued":
+			icon = m.spinner.View()
+		}
+
+		row := fmt.Sprintf("%s %s  %s", icon, padRight(truncate(filepath.Base(file.Path), nameWidth), nameWidth),
+			padRight(truncate(file.Stage, 28), 28))
+		if fraction := file.fraction(); fraction >= 0 && !file.Finished {
+			row += fmt.Sprintf("  %s %3.0f%%", progressBar(fraction, 20), fraction*100)
+			if eta := file.eta(); eta > 0 {
+				row += subtitleStyle.Render("  ETA " + formatETA(eta))
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	status := fmt.Sprintf("%d of %d files done, %d at a time", done, len(m.files), m.jobs)
+	if failed > 0 {
+		status += fmt.Sprintf(", %d failed", failed)
+	}
+	help := "Ctrl+C to cancel"
+	if m.cancelling {
+		help = "Cancelling..."
+	}
+
+	return fmt.Sprintf("%s\n\n%s\n\n%s\n",
+		subtitleStyle.Render(status),
+		strings.Join(rows, "\n"),
+		subtitleStyle.Render(help))
+}
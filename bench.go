@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// benchResult is one model/backend combination in a benchmark
+type benchResult struct {
+	Model   string
+	Backend string
+	Stats   RunStats
+	WER     float64 // -1 without a reference transcript
+	Err     error
+}
+
+// runBench transcribes a file with every selected model and backend and
+// prints speed, memory and accuracy in a table
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	cfg, err := addConfigFlags(fs)
+	if err != nil {
+		return err
+	}
+	modelList := fs.String("models", "", "comma-separated model sizes to run (default: --model)")
+	backendList := fs.String("backends", "", "comma-separated backends to run (default: --backend)")
+	refPath := fs.String("ref", "", "reference transcript for word error rate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected exactly one input file")
+	}
+
+	selectedModels := splitList(*modelList, cfg.Model)
+	selectedBackends := splitList(*backendList, cfg.Backend)
+
+	var reference string
+	if *refPath != "" {
+		data, err := os.ReadFile(*refPath)
+		if err != nil {
+			return fmt.Errorf("failed to read reference transcript: %w", err)
+		}
+		reference = string(data)
+	}
+
+	// Validate every combination before spending time on any of them
+	var runs []Config
+	for _, backend := range selectedBackends {
+		for _, model := range selectedModels {
+			run := *cfg
+			run.Model = model
+			run.Backend = backend
+			if err := run.validate(); err != nil {
+				return err
+			}
+			runs = append(runs, run)
+		}
+	}
+
+	path := fs.Arg(0)
+	var results []benchResult
+	for i, run := range runs {
+		fmt.Printf("[%d/%d] %s with %s\n", i+1, len(runs), run.Model, run.Backend)
+
+		transcript, stats, err := runPipeline(context.Background(), path, run, PipelineHooks{})
+		result := benchResult{Model: run.Model, Backend: run.Backend, Stats: stats, WER: -1, Err: err}
+		if err == nil && *refPath != "" {
+			result.WER = wordErrorRate(reference, transcript.Text)
+		}
+		results = append(results, result)
+	}
+
+	fmt.Println()
+	printBenchTable(results)
+	return nil
+}
+
+// splitList splits a comma-separated flag value, falling back to def when
+// it is empty
+func splitList(value, def string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return []string{def}
+	}
+	return items
+}
+
+// printBenchTable prints the benchmark results. The real-time factor is the
+// transcription time divided by the audio duration, so lower is faster.
+func printBenchTable(results []benchResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tBACKEND\tTIME\tRTF\tPEAK MEMORY\tWER")
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(w, "%s\t%s\tfailed: %v\t\t\t\n", r.Model, r.Backend, r.Err)
+			continue
+		}
+
+		rtf, memory, wer := "-", "-", "-"
+		if r.Stats.AudioDuration > 0 {
+			rtf = fmt.Sprintf("%.2f", r.Stats.Transcribing.Seconds()/r.Stats.AudioDuration)
+		}
+		if r.Stats.PeakMemory > 0 {
+			memory = formatSize(int64(r.Stats.PeakMemory))
+		}
+		if r.WER >= 0 {
+			wer = fmt.Sprintf("%.1f%%", r.WER*100)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Model, r.Backend, r.Stats.Transcribing.Round(time.Second), rtf, memory, wer)
+	}
+	w.Flush()
+}
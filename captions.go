@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// isCaptionFormat reports whether a format's segments are shown as
+// captions, and so are shaped by the caption rules
+func isCaptionFormat(format string) bool {
+	switch format {
+	case "srt", "vtt", "ttml", "dfxp":
+		return true
+	}
+	return false
+}
+
+// renderFormat renders a transcript in a format, first shaping its segments
+// into cues for the caption formats
+func renderFormat(t *Transcript, format string, cfg Config) ([]byte, error) {
+	if isCaptionFormat(format) {
+		t = shapeCaptions(t, cfg.Captions)
+	}
+	return formatTranscript(t, format, cfg.Timestamps)
+}
+
+// captionToken is a word of a caption with its timing. glued tokens are
+// pieces of a word too long for a line and follow the previous piece
+// without a space.
+type captionToken struct {
+	text       string
+	start, end float64
+	glued      bool
+}
+
+// captionTokens splits a segment into timed words. Word timings are used
+// when they line up with the text; otherwise the segment's time is shared
+// out by length. Words wider than width are broken between characters.
+func captionTokens(seg Segment, width int) []captionToken {
+	fields := strings.Fields(seg.Text)
+	timed := seg.wordConfidences() != nil
+
+	total := 0
+	for _, field := range fields {
+		total += len(field)
+	}
+	var tokens []captionToken
+	at, done := seg.Start, 0
+	for i, field := range fields {
+		start, end := at, at
+		if timed {
+			start, end = seg.Words[i].Start, seg.Words[i].End
+		} else if total > 0 {
+			done += len(field)
+			end = seg.Start + (seg.End-seg.Start)*float64(done)/float64(total)
+		}
+		at = end
+
+		if width <= 0 || textWidth(field) <= width {
+			tokens = append(tokens, captionToken{text: field, start: start, end: end})
+			continue
+		}
+		// Pieces share the word's time by length
+		clusters := graphemes(field)
+		piece, from, glued := "", 0, false
+		for i, cluster := range clusters {
+			if piece != "" && textWidth(piece+cluster) > width {
+				tokens = append(tokens, captionToken{piece, lerp(start, end, from, len(clusters)), lerp(start, end, i, len(clusters)), glued})
+				piece, from, glued = "", i, true
+			}
+			piece += cluster
+		}
+		tokens = append(tokens, captionToken{piece, lerp(start, end, from, len(clusters)), end, glued})
+	}
+	return tokens
+}
+
+// lerp returns the time part/whole of the way from start to end
+func lerp(start, end float64, part, whole int) float64 {
+	return start + (end-start)*float64(part)/float64(whole)
+}
+
+// captionLines breaks tokens into lines of at most width columns, or a
+// single line when width is 0. The first line leaves room for a label of
+// labelWidth columns.
+func captionLines(tokens []captionToken, width, labelWidth int) []string {
+	var lines []string
+	line := ""
+	for _, token := range tokens {
+		sep := " "
+		if token.glued || line == "" {
+			sep = ""
+		}
+		used := textWidth(line + sep + token.text)
+		if len(lines) == 0 {
+			used += labelWidth
+		}
+		if line != "" && width > 0 && used > width {
+			lines = append(lines, line)
+			line, sep = "", ""
+		}
+		line += sep + token.text
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// balancedLines breaks tokens into as few lines as captionLines does but
+// with the lines as even as possible, which reads better than a full line
+// over a short one
+func balancedLines(tokens []captionToken, width, labelWidth int) []string {
+	lines := captionLines(tokens, width, labelWidth)
+	if width <= 0 || len(lines) < 2 {
+		return lines
+	}
+	for narrower := width - 1; narrower > labelWidth; narrower-- {
+		candidate := captionLines(tokens, narrower, labelWidth)
+		if len(candidate) > len(lines) {
+			break
+		}
+		for i, line := range candidate {
+			if i == 0 {
+				line = strings.Repeat(" ", labelWidth) + line
+			}
+			if textWidth(line) > narrower {
+				return lines // a piece is wider than the line
+			}
+		}
+		lines = candidate
+	}
+	return lines
+}
+
+// shapeCaptions returns a copy of the transcript whose segments are cues
+// that follow the caption rules: long segments are split between words so
+// that each cue fits in rules.MaxLines lines of rules.MaxLineLength
+// characters and lasts at most rules.MaxDuration seconds, and cues end
+// rules.MinGap seconds before the next one starts.
+func shapeCaptions(t *Transcript, rules CaptionConfig) *Transcript {
+	shaped := *t
+	shaped.Segments = nil
+	for _, seg := range t.Segments {
+		// Room for the speaker and language labels formatSRT adds
+		labelWidth := 0
+		if seg.Speaker != "" {
+			labelWidth += textWidth(seg.Speaker + ": ")
+		}
+		if seg.foreign(t) {
+			labelWidth += textWidth("[" + seg.Language + "] ")
+		}
+		fits := func(tokens []captionToken) bool {
+			if rules.MaxDuration > 0 && len(tokens) > 1 && tokens[len(tokens)-1].end-tokens[0].start > rules.MaxDuration {
+				return false
+			}
+			return rules.MaxLines <= 0 || len(captionLines(tokens, rules.MaxLineLength, labelWidth)) <= rules.MaxLines
+		}
+
+		tokens := captionTokens(seg, rules.MaxLineLength)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		// Each cue takes words until the next one would break a rule
+		var cues [][]captionToken
+		first := 0
+		for i := 1; i < len(tokens); i++ {
+			if !fits(tokens[first : i+1]) {
+				cues = append(cues, tokens[first:i])
+				first = i
+			}
+		}
+		cues = append(cues, tokens[first:])
+
+		for i, cue := range cues {
+			part := seg
+			part.Start, part.End = cue[0].start, cue[len(cue)-1].end
+			if i == 0 {
+				part.Start = seg.Start
+			}
+			if i == len(cues)-1 {
+				part.End = seg.End
+			}
+			part.Text = strings.Join(balancedLines(cue, rules.MaxLineLength, labelWidth), "\n")
+			part.Words = nil
+			shaped.Segments = append(shaped.Segments, part)
+		}
+	}
+
+	if rules.MinGap > 0 {
+		for i := 0; i+1 < len(shaped.Segments); i++ {
+			cue, next := &shaped.Segments[i], shaped.Segments[i+1]
+			if next.Start-cue.End < rules.MinGap {
+				cue.End = math.Max(cue.Start, next.Start-rules.MinGap)
+			}
+		}
+	}
+	return &shaped
+}
+
+// String describes the rules for --dry-run
+func (c CaptionConfig) String() string {
+	var rules []string
+	if c.MaxLineLength > 0 {
+		rules = append(rules, fmt.Sprintf("%d characters per line", c.MaxLineLength))
+	}
+	if c.MaxLines > 0 {
+		rules = append(rules, fmt.Sprintf("%d lines per cue", c.MaxLines))
+	}
+	if c.MaxDuration > 0 {
+		rules = append(rules, fmt.Sprintf("cues up to %g seconds", c.MaxDuration))
+	}
+	if c.MinGap > 0 {
+		rules = append(rules, fmt.Sprintf("%g seconds between cues", c.MinGap))
+	}
+	if len(rules) == 0 {
+		return "off"
+	}
+	return strings.Join(rules, ", ")
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Chapter is a titled section of a recording
+type Chapter struct {
+	Start float64
+	End   float64
+	Title string
+}
+
+// Chapter detection tuning: boundaries are scored on the pause before a
+// segment and how much the vocabulary changes around it
+const (
+	chapterWindow    = 90.0 // seconds of speech compared on each side
+	chapterPause     = 4.0  // a pause this long counts fully
+	chapterThreshold = 0.6  // minimum score for a boundary
+)
+
+// stopwords are skipped when looking for the words that characterize a
+// stretch of speech
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`a about after again all also am an and any are as at be because
+		been before being but by can could did do does doing don't down during each few for from get
+		got had has have having he her here hers him his how i i'm if in into is it it's its just
+		know like me more most my no nor not now of off on once only or other our out over own really
+		right said same she so some such than that that's the their them then there these they thing
+		things think this those through to too um uh under until up us very was we well were what
+		when where which while who why will with would yeah yes you your going want mean okay oh`) {
+		stopwords[word] = true
+	}
+}
+
+// keywordCounts counts the content words of text
+func keywordCounts(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, word := range normalizedWords(text) {
+		if len([]rune(word)) < 3 || stopwords[word] {
+			continue
+		}
+		counts[word]++
+	}
+	return counts
+}
+
+// topKeywords returns the n most frequent content words of text
+func topKeywords(text string, n int) []string {
+	counts := keywordCounts(text)
+	words := make([]string, 0, len(counts))
+	for word := range counts {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	if len(words) > n {
+		words = words[:n]
+	}
+	return words
+}
+
+// vocabularySimilarity is the cosine similarity of two keyword counts
+func vocabularySimilarity(a, b map[string]int) float64 {
+	var dot, normA, normB float64
+	for word, count := range a {
+		dot += float64(count * b[word])
+		normA += float64(count * count)
+	}
+	for _, count := range b {
+		normB += float64(count * count)
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// windowText joins the text of segments within span seconds before (span
+// < 0) or after index
+func windowText(segments []Segment, index int, span float64) string {
+	var parts []string
+	if span < 0 {
+		from := segments[index].Start + span
+		for i := index - 1; i >= 0 && segments[i].End > from; i-- {
+			parts = append(parts, segments[i].Text)
+		}
+	} else {
+		to := segments[index].Start + span
+		for i := index; i < len(segments) && segments[i].Start < to; i++ {
+			parts = append(parts, segments[i].Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// detectChapters splits a transcript at long pauses and shifts in
+// vocabulary, keeping chapters at least minLength seconds long
+func detectChapters(t *Transcript, minLength float64) []Chapter {
+	segments := t.Segments
+	if len(segments) == 0 {
+		return nil
+	}
+	end := t.Duration
+	if end == 0 {
+		end = t.duration()
+	}
+
+	type candidate struct {
+		index int
+		score float64
+	}
+	var candidates []candidate
+	for i := 1; i < len(segments); i++ {
+		pause := segments[i].Start - segments[i-1].End
+		before := keywordCounts(windowText(segments, i, -chapterWindow))
+		after := keywordCounts(windowText(segments, i, chapterWindow))
+		shift := 1 - vocabularySimilarity(before, after)
+
+		score := 0.5*shift + 0.5*math.Min(pause/chapterPause, 1)
+		if score >= chapterThreshold {
+			candidates = append(candidates, candidate{i, score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	// Take the strongest boundaries that keep every chapter long enough
+	starts := []float64{0}
+	indexes := []int{0}
+	for _, c := range candidates {
+		start := segments[c.index].Start
+		if end-start < minLength {
+			continue
+		}
+		tooClose := false
+		for _, s := range starts {
+			if start-s < minLength && s-start < minLength {
+				tooClose = true
+				break
+			}
+		}
+		if !tooClose {
+			starts = append(starts, start)
+			indexes = append(indexes, c.index)
+		}
+	}
+	sort.Ints(indexes)
+
+	chapters := make([]Chapter, len(indexes))
+	for i, index := range indexes {
+		last := len(segments)
+		chapterEnd := end
+		if i+1 < len(indexes) {
+			last = indexes[i+1]
+			chapterEnd = segments[last].Start
+		}
+
+		var texts []string
+		for _, seg := range segments[index:last] {
+			texts = append(texts, seg.Text)
+		}
+
+		start := segments[index].Start
+		if i == 0 {
+			start = 0
+		}
+		chapters[i] = Chapter{Start: start, End: chapterEnd, Title: chapterTitle(strings.Join(texts, " "), i)}
+	}
+	chapters[0].Title = "Intro"
+	return chapters
+}
+
+// chapterTitle names a chapter after its most frequent content words
+func chapterTitle(text string, index int) string {
+	keywords := topKeywords(text, 3)
+	if len(keywords) == 0 {
+		return fmt.Sprintf("Part %d", index+1)
+	}
+	for i, word := range keywords {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		keywords[i] = string(runes)
+	}
+	return strings.Join(keywords, ", ")
+}
+
+// chapterTimestamp formats a chapter start the way YouTube expects:
+// MM:SS, or H:MM:SS for long videos
+func chapterTimestamp(seconds float64, long bool) string {
+	total := int(seconds)
+	h, m, s := total/3600, total%3600/60, total%60
+	if long {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
+
+// formatYouTubeChapters renders chapters as lines for a video description
+func formatYouTubeChapters(chapters []Chapter) string {
+	long := len(chapters) > 0 && chapters[len(chapters)-1].Start >= 3600
+
+	var sb strings.Builder
+	for _, chapter := range chapters {
+		fmt.Fprintf(&sb, "%s %s\n", chapterTimestamp(chapter.Start, long), chapter.Title)
+	}
+	return sb.String()
+}
+
+// ffmetadataEscaper escapes the characters FFMETADATA treats specially
+var ffmetadataEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n")
+
+// formatFFMetadata renders chapters in ffmpeg's metadata file format
+func formatFFMetadata(chapters []Chapter) string {
+	var sb strings.Builder
+	sb.WriteString(";FFMETADATA1\n")
+	for _, chapter := range chapters {
+		fmt.Fprintf(&sb, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			int64(chapter.Start*1000), int64(chapter.End*1000), ffmetadataEscaper.Replace(chapter.Title))
+	}
+	return sb.String()
+}
+
+// chapterContainers are the formats ffmpeg can store chapters in
+var chapterContainers = map[string]bool{
+	".mp4": true, ".m4a": true, ".m4b": true, ".mov": true, ".mkv": true, ".mka": true,
+}
+
+// embedChapters copies the input to outputPath with the chapters added,
+// without re-encoding
+func embedChapters(ffmpegPath, inputPath, outputPath string, chapters []Chapter) error {
+	metadata, err := os.CreateTemp("", "stt-chapters-*.txt")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(metadata.Name())
+
+	if _, err := metadata.WriteString(formatFFMetadata(chapters)); err != nil {
+		metadata.Close()
+		return err
+	}
+	if err := metadata.Close(); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(ffmpegPath,
+		"-i", inputPath,
+		"-i", metadata.Name(),
+		"-map", "0",
+		"-map_metadata", "0",
+		"-map_chapters", "1",
+		"-codec", "copy",
+		outputPath,
+		"-y",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg error: %s", string(output))
+	}
+	return nil
+}
+
+// runChapters transcribes a file, detects chapters and writes them in the
+// YouTube description format, optionally embedding them into the video
+func runChapters(args []string) error {
+	fs := flag.NewFlagSet("chapters", flag.ContinueOnError)
+	cfg, err := addConfigFlags(fs)
+	if err != nil {
+		return err
+	}
+	minLength := fs.Duration("min-length", 2*time.Minute, "shortest chapter to create")
+	embed := fs.Bool("embed", false, "also write a copy of the input with the chapters embedded")
+	outputDir := fs.String("output-dir", "", "directory to write the chapter list to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected exactly one input file")
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	// YouTube ignores chapters shorter than 10 seconds
+	if *minLength < 10*time.Second {
+		return fmt.Errorf("--min-length must be at least 10s")
+	}
+
+	path := fs.Arg(0)
+	ext := strings.ToLower(filepath.Ext(path))
+	if *embed && (isRemoteInput(path) || !chapterContainers[ext]) {
+		return fmt.Errorf("--embed needs a local MP4, M4A, M4B, MOV or MKV file")
+	}
+
+	transcript, err := processAudioSTT(path, *cfg)
+	if err != nil {
+		return err
+	}
+
+	chapters := detectChapters(transcript, minLength.Seconds())
+	if len(chapters) < 3 {
+		fmt.Println("Note: YouTube needs at least three chapters; try a smaller --min-length")
+	}
+
+	list := formatYouTubeChapters(chapters)
+	fmt.Print(list)
+
+	listPath := transcriptPath(path, *outputDir, ".chapters.txt")
+	if err := os.WriteFile(listPath, []byte(list), 0644); err != nil {
+		return fmt.Errorf("failed to write chapters: %w", err)
+	}
+	fmt.Printf("Saved %s\n", listPath)
+
+	if *embed {
+		ffmpegPath, err := findFFmpeg()
+		if err != nil {
+			return err
+		}
+		videoPath := transcriptPath(path, *outputDir, ".chapters"+filepath.Ext(path))
+		if err := embedChapters(ffmpegPath, path, videoPath, chapters); err != nil {
+			return fmt.Errorf("failed to embed chapters: %w", err)
+		}
+		fmt.Printf("Saved %s\n", videoPath)
+	}
+	return nil
+}
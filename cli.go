@@ -0,0 +1,515 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const usageText = `Usage:
+  stt-cli [--no-color] [--ascii]  Start the interactive file picker
+  stt-cli --plain                 Prompt for files with plain line-oriented output
+  stt-cli demo [--plain]          Transcribe a built-in sample clip to check the setup
+  stt-cli transcribe [flags] FILE|URL...
+                                  Transcribe files without the TUI
+  stt-cli podcast [flags] FEED_URL
+                                  Pick episodes from a podcast feed and transcribe them
+  stt-cli record [flags]          Record the microphone or system audio, then transcribe it
+  stt-cli compare [flags] FILE    Transcribe with two models and show the differences
+  stt-cli bench [flags] FILE      Measure speed, memory and accuracy of models and backends
+  stt-cli eval --ref REF FILE     Score a transcription against a reference transcript
+  stt-cli chapters [flags] FILE   Detect chapters and write them in YouTube's format
+  stt-cli align --text SCRIPT FILE
+                                  Time an existing transcript or script against the audio
+  stt-cli clips --query PHRASE FILE
+                                  Cut audio clips of the places a phrase is spoken
+  stt-cli serve [flags]           Run the HTTP and gRPC transcription APIs
+  stt-cli jobs list|show|retry|prune
+                                  Browse, rerun and remove recorded transcription jobs
+  stt-cli self-update [--check]   Install the latest release
+  stt-cli version                 Print the version
+
+Inputs may be local paths or s3://, sftp://, http:// and https:// URLs.
+
+Transcribe flags:
+  --stdin          Read newline-separated file paths from standard input
+  --output-dir DIR Write transcripts to DIR instead of next to each input
+  -o, --output FILE
+                   Write the transcript of a single input to FILE, or to
+                   standard output with -o - (progress goes to stderr)
+  --jobs-db FILE   Record jobs in FILE (default: jobs.db next to the config file)
+  --detect-language
+                   Sample the file and report its languages before transcribing
+  --detect-only    Only report the languages, don't transcribe
+  --jobs N         Transcribe N files at the same time (default 1)
+  --plain          Print a line per stage instead of the progress dashboard
+  --keep-partial   Save the segments finished before a file fails or is
+                   cancelled, as NAME.partial.txt and so on
+  --merge          Merge the files, in the order given, into one transcript with
+                   continuous timestamps (FIRST.merged.txt, or --output); with
+                   --diarize, each file's speakers get their own labels
+  --manifest       Write NAME.manifest.json with the tool versions, settings,
+                   input hash and timings of each run, for reproducing it
+  --dry-run        Print the tools, commands, model and output files each file
+                   would use, without running anything
+
+Transcription flags (transcribe, record, podcast, compare, bench, eval, chapters, align, clips, serve) override the config file:
+  --preset NAME    Apply a named preset (flags after it override its values)
+  --model NAME     Whisper model size: tiny, base, small, medium, large-v3
+  --language CODE  Spoken language, or auto to detect it
+  --backend NAME   whisper or faster-whisper
+  -f, --format LIST
+                   Output formats: txt, srt, vtt, ttml, dfxp, json, anki, minutes,
+                   edl or markers, comma-separated
+  --template FILE  Format transcripts with a Go text/template instead
+  --translate      Translate the speech to English
+  --code-switching Detect each segment's language and tag segments in other languages
+  --diarize        Label each segment with its speaker (needs HF_TOKEN, see README)
+  --analyze        Measure the tone of each segment (English) and find keywords and topics
+  --mode meeting   Identify speakers and also write minutes with decisions and action items
+  --numbers        Write numbers as digits: "twenty five percent" -> "25%" (English)
+  --dates          Write dates as dates: "march third" -> "March 3" (English)
+  --currency       Use currency symbols: "five dollars" -> "$5" (English)
+  --capitalize     Capitalize sentence starts, "I" and proper nouns
+  --corrections FILE
+                   Fix recurring mistakes with a find-and-replace file
+  --boost LIST     Names and terms the model should favour, comma-separated
+  --caption-line-length N, --caption-lines N, --caption-duration S, --caption-gap S
+                   Shape SRT, VTT, TTML and DFXP cues (default 42 characters,
+                   2 lines, 7 seconds, 0.08 seconds apart; 0 turns a rule off)
+  --timestamps STYLE
+                   Show times in the viewer and minutes as clock (HH:MM:SS),
+                   seconds or frames (HH:MM:SS:FF timecode)
+  --fps RATE       Frame rate for frames, edl and markers (default 25;
+                   29.97 and 59.94 use drop-frame timecode)
+  --low-memory     Use the base model at most, decode the audio in chunks and
+                   run one file at a time, for machines with 4 GB of memory
+
+Record flags:
+  --duration D     Stop after D (e.g. 45m); by default records until Enter is pressed
+  --source S       mic (default) or system
+  --device NAME    Capture device to use (see --list-devices)
+  --output FILE    Where to save the recording (default: recording-<timestamp>.wav)
+  --list-devices   List capture devices and exit
+
+Compare flags:
+  --model-b NAME   Model size for the second transcription
+  --backend-b NAME Backend for the second transcription
+  --width N        Width of the side-by-side output (default 100)
+
+Bench flags:
+  --models LIST    Comma-separated model sizes to run (default: --model)
+  --backends LIST  Comma-separated backends to run (default: --backend)
+  --ref FILE       Reference transcript for word error rate
+
+Eval flags:
+  --ref FILE       Ground-truth transcript to compare against (required)
+  --width N        Width of the alignment output (default 100)
+
+Chapters flags:
+  --min-length D   Shortest chapter to create (default 2m)
+  --embed          Also write a copy of the video with the chapters embedded
+  --output-dir DIR Write the chapter list (and video) to DIR
+
+Align flags:
+  --text FILE      Transcript or script whose words are spoken in FILE (required)
+  --output-dir DIR Write the timed transcript to DIR
+
+Clips flags:
+  --query PHRASE   Phrase to look for, ignoring case (required)
+  --transcript FILE
+                   Search a transcript saved as json instead of transcribing
+  --context N      Segments to include before and after each match (default 0)
+  --padding D      Extra audio around each clip (default 250ms)
+  --clip-format EXT
+                   Audio format of the clips: mp3 (default), m4a, wav, ...
+  --output-dir DIR Write the clips to DIR
+
+Serve flags:
+  --http ADDR      Address for the HTTP API (default 127.0.0.1:8080; empty disables it)
+  --grpc ADDR      Address for the gRPC API (disabled by default)
+  --allow-paths    Let clients transcribe paths and URLs on the server
+  --metrics ADDR   Serve Prometheus metrics on ADDR instead of the HTTP API
+  --max-jobs N     Jobs to run at the same time (default 1)
+  --job-timeout D  Cancel jobs running longer than D (default: no limit)
+  --drain-timeout D
+                   Time to let running jobs finish on shutdown (default 5m)
+  --tokens-file FILE
+                   Require a bearer token listed in FILE
+  --oidc-issuer URL, --oidc-audience ID
+                   Accept ID tokens from an OIDC provider
+  --max-upload SIZE
+                   Reject uploads larger than SIZE, e.g. 500MB
+  --max-duration D Reject audio longer than D, e.g. 2h
+  --jobs-db FILE   Record jobs in FILE (default: jobs.db next to the config file)
+
+Jobs commands:
+  jobs list [--status S] [--limit N]
+                   Show recent jobs, newest first (default 20)
+  jobs show ID     Show a job's settings, timings, outputs and transcript
+  jobs retry ID    Run a job again with the settings it was submitted with
+  jobs prune [--days N]
+                   Remove jobs finished more than N days ago and their kept
+                   transcripts (default: history.retention_days)
+  --db FILE        Job database to read (default: jobs.db next to the config file)
+
+Podcast flags:
+  --output-dir DIR Write episode transcripts to DIR (default: current directory)
+  --plain          Choose episodes by number instead of the interactive list
+`
+
+// runCommand dispatches a command-line subcommand and returns the exit code
+func runCommand(args []string) int {
+	var err error
+
+	switch args[0] {
+	case "transcribe":
+		err = runTranscribe(args[1:])
+	case "demo":
+		err = runDemo(args[1:])
+	case "record":
+		err = runRecord(args[1:])
+	case "podcast":
+		err = runPodcast(args[1:])
+	case "compare":
+		err = runCompare(args[1:])
+	case "bench":
+		err = runBench(args[1:])
+	case "eval":
+		err = runEval(args[1:])
+	case "chapters":
+		err = runChapters(args[1:])
+	case "align":
+		err = runAlign(args[1:])
+	case "clips":
+		err = runClips(args[1:])
+	case "serve":
+		err = runServe(args[1:])
+	case "jobs":
+		err = runJobs(args[1:])
+	case "self-update":
+		err = runSelfUpdate(args[1:])
+	case "version":
+		fmt.Println("stt-cli", version)
+		return 0
+	case "help", "-h", "--help":
+		fmt.Print(usageText)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", args[0], usageText)
+		return 2
+	}
+
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runTranscribe transcribes every file given on the command line or stdin
+func runTranscribe(args []string) error {
+	fs := flag.NewFlagSet("transcribe", flag.ContinueOnError)
+	fromStdin := fs.Bool("stdin", false, "read newline-separated file paths from standard input")
+	outputDir := fs.String("output-dir", "", "directory to write transcripts to")
+	output := fs.String("output", "", "file to write the transcript to, or - for standard output")
+	fs.StringVar(output, "o", "", "shorthand for --output")
+	jobsDB := fs.String("jobs-db", "", "job history database (default: jobs.db next to the config file)")
+	detectLanguage := fs.Bool("detect-language", false, "sample the file and report its languages before transcribing")
+	detectOnly := fs.Bool("detect-only", false, "only report the languages, don't transcribe")
+	dryRun := fs.Bool("dry-run", false, "print what would be run and written for each file, without running it")
+	jobs := fs.Int("jobs", 1, "number of files to transcribe at the same time")
+	plain := fs.Bool("plain", false, "print a line per stage instead of the progress dashboard")
+	keepPartial := fs.Bool("keep-partial", false, "save the segments finished before a file fails or is cancelled")
+	merge := fs.Bool("merge", false, "merge the files' transcripts into one with continuous timestamps; speakers are labelled apart per file")
+	manifest := fs.Bool("manifest", false, "write a manifest of versions, settings, hashes and timings next to each transcript")
+	cfg, err := addConfigFlags(fs)
+	if err != nil {
+		return err
+	}
+	paths, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	if *fromStdin {
+		stdinPaths, err := readPathList(os.Stdin)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, stdinPaths...)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no input files given (pass paths as arguments or use --stdin)")
+	}
+	if *jobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1")
+	}
+	if cfg.LowMemory && *jobs > 1 {
+		fmt.Fprintf(os.Stderr, "Warning: --low-memory transcribes one file at a time, not %d\n", *jobs)
+		*jobs = 1
+	}
+	if *merge && *detectOnly {
+		return fmt.Errorf("--merge needs transcripts; it can't be used with --detect-only")
+	}
+	if *output != "" {
+		if err := checkSingleOutput(paths, *outputDir, *cfg, *merge); err != nil {
+			return err
+		}
+	}
+
+	opts := batchOptions{
+		Config:         *cfg,
+		OutputDir:      *outputDir,
+		Output:         *output,
+		DetectLanguage: *detectLanguage,
+		DetectOnly:     *detectOnly,
+		KeepPartial:    *keepPartial,
+		Manifest:       *manifest,
+		Merge:          *merge,
+	}
+	if *dryRun {
+		for i, path := range paths {
+			fmt.Printf("[%d/%d] %s\n", i+1, len(paths), path)
+			planPipeline(os.Stdout, path, opts)
+		}
+		if *merge {
+			fmt.Println("Merged transcript")
+			for _, path := range mergedOutputs(paths, opts) {
+				fmt.Printf("    %s\n", path)
+			}
+		}
+		return nil
+	}
+
+	if *outputDir != "" {
+		if err := os.MkdirAll(*outputDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	// Job history is a convenience; a broken database doesn't stop the batch
+	store, err := openJobStore(*jobsDB, cfg.History)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: job history disabled: %v\n", err)
+	}
+	defer store.Close()
+
+	dashboard := !*plain && !isDumbTerminal() && isTerminal(opts.statusOutput())
+	results := runBatch(paths, *jobs, opts, store, dashboard)
+
+	failed := 0
+	for _, file := range results {
+		if file.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 && *merge {
+		return fmt.Errorf("%d of %d files failed, so the transcripts weren't merged", failed, len(paths))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(paths))
+	}
+	if *merge {
+		return saveMerged(paths, results, opts)
+	}
+	return nil
+}
+
+// parseInterspersed parses flags given before or after the file names, as
+// in "transcribe talk.mp4 -f srt -o -", and returns the file names.
+// Everything after "--" is a file name.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// checkSingleOutput rejects --output with anything that would need more
+// than one file: several inputs that aren't merged, several formats or
+// Anki decks
+func checkSingleOutput(paths []string, outputDir string, cfg Config, merge bool) error {
+	if len(paths) > 1 && !merge {
+		return fmt.Errorf("--output takes a single input file, not %d; use --output-dir for several", len(paths))
+	}
+	if outputDir != "" {
+		return fmt.Errorf("--output and --output-dir can't be used together")
+	}
+	if cfg.Template != "" {
+		return nil
+	}
+	if formats := cfg.formats(); len(formats) > 1 {
+		return fmt.Errorf("--output takes a single format, not %s", strings.Join(formats, ","))
+	}
+	if cfg.formats()[0] == "anki" {
+		return fmt.Errorf("anki flashcards can only be saved to files with --output-dir")
+	}
+	return nil
+}
+
+// writeTranscriptOutput writes the transcript in its single format, or
+// with the template, to path, or to standard output when path is "-"
+func writeTranscriptOutput(t *Transcript, path string, cfg Config) error {
+	if cfg.MarkLowConfidence {
+		t = markLowConfidence(t, cfg.ConfidenceThreshold, cfg.LowConfidenceMarker)
+	}
+
+	var data []byte
+	var err error
+	if cfg.Template != "" {
+		data, err = renderTemplate(t, cfg.Template)
+	} else {
+		data, err = renderFormat(t, cfg.formats()[0], cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	if path == "-" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("failed to write transcript: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
+
+// transcriptPath builds the output path for an input file, placing it next
+// to the input unless an output directory is given. Remote inputs are
+// written to the current directory.
+func transcriptPath(inputPath, outputDir, ext string) string {
+	name := filepath.Base(inputPath)
+	dir := filepath.Dir(inputPath)
+	if isRemoteInput(inputPath) {
+		name = remoteBaseName(inputPath)
+		dir = "."
+	}
+
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	if outputDir != "" {
+		dir = outputDir
+	}
+	return filepath.Join(dir, base+ext)
+}
+
+// addConfigFlags loads the config file and registers flags that override
+// its transcription settings
+func addConfigFlags(fs *flag.FlagSet) (*Config, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Presets are applied where they appear, so later flags override them
+	fs.Func("preset", "apply a named preset", func(name string) error {
+		return cfg.applyPreset(name)
+	})
+	fs.StringVar(&cfg.Model, "model", cfg.Model, "Whisper model size")
+	fs.StringVar(&cfg.Language, "language", cfg.Language, "spoken language, or auto to detect it")
+	fs.StringVar(&cfg.Backend, "backend", cfg.Backend, "transcription backend")
+	fs.StringVar(&cfg.OutputFormat, "format", cfg.OutputFormat, "comma-separated output formats")
+	fs.StringVar(&cfg.OutputFormat, "f", cfg.OutputFormat, "shorthand for --format")
+	fs.StringVar(&cfg.Template, "template", cfg.Template, "text/template file to format transcripts with")
+	fs.BoolVar(&cfg.Translate, "translate", cfg.Translate, "translate the speech to English")
+	fs.BoolVar(&cfg.CodeSwitching, "code-switching", cfg.CodeSwitching, "tag each segment with its language")
+	fs.BoolVar(&cfg.Diarize, "diarize", cfg.Diarize, "label each segment with its speaker")
+	fs.BoolVar(&cfg.Analyze, "analyze", cfg.Analyze, "measure the tone of each segment and find keywords")
+	fs.Func("mode", "set up the pipeline for a kind of recording: meeting", func(mode string) error {
+		return cfg.applyMode(mode)
+	})
+	fs.BoolVar(&cfg.FormatNumbers, "numbers", cfg.FormatNumbers, "write spoken numbers as digits")
+	fs.BoolVar(&cfg.FormatDates, "dates", cfg.FormatDates, "write spoken dates as dates")
+	fs.BoolVar(&cfg.FormatCurrency, "currency", cfg.FormatCurrency, "write spoken amounts with currency symbols")
+	fs.BoolVar(&cfg.Capitalize, "capitalize", cfg.Capitalize, "capitalize sentence starts and proper nouns")
+	fs.StringVar(&cfg.Corrections, "corrections", cfg.Corrections, "find-and-replace file applied to transcripts")
+	fs.BoolVar(&cfg.LowMemory, "low-memory", cfg.LowMemory, "use a small model and decode the audio in chunks, one file at a time")
+	fs.IntVar(&cfg.Captions.MaxLineLength, "caption-line-length", cfg.Captions.MaxLineLength, "most characters per caption line, 0 for no limit")
+	fs.IntVar(&cfg.Captions.MaxLines, "caption-lines", cfg.Captions.MaxLines, "most lines per caption, 0 for no limit")
+	fs.Float64Var(&cfg.Captions.MaxDuration, "caption-duration", cfg.Captions.MaxDuration, "most seconds a caption stays up, 0 for no limit")
+	fs.Float64Var(&cfg.Captions.MinGap, "caption-gap", cfg.Captions.MinGap, "seconds between captions, 0 to let them touch")
+	fs.StringVar(&cfg.Timestamps.Style, "timestamps", cfg.Timestamps.Style, "how times are written: clock, seconds or frames")
+	fs.StringVar(&cfg.Timestamps.FrameRate, "fps", cfg.Timestamps.FrameRate, "frame rate for frames timestamps and EDL and marker exports")
+	fs.Func("boost", "comma-separated names and terms the model should favour", func(value string) error {
+		cfg.BoostPhrases = nil
+		for _, phrase := range strings.Split(value, ",") {
+			if phrase = strings.TrimSpace(phrase); phrase != "" {
+				cfg.BoostPhrases = append(cfg.BoostPhrases, phrase)
+			}
+		}
+		return nil
+	})
+
+	return &cfg, nil
+}
+
+// writeTranscripts saves a transcript next to base (a path without an
+// extension) in every configured output format, or with the configured
+// template, and returns the paths written. Partial transcripts get
+// partialSuffix in their names.
+func writeTranscripts(t *Transcript, base string, cfg Config) ([]string, error) {
+	if t.Partial {
+		base += partialSuffix
+	}
+	if cfg.MarkLowConfidence {
+		t = markLowConfidence(t, cfg.ConfidenceThreshold, cfg.LowConfidenceMarker)
+	}
+
+	if cfg.Template != "" {
+		data, err := renderTemplate(t, cfg.Template)
+		if err != nil {
+			return nil, err
+		}
+		path := base + templateExtension(cfg.Template)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write transcript: %w", err)
+		}
+		return []string{path}, nil
+	}
+
+	var paths []string
+	for _, format := range cfg.formats() {
+		if format == "anki" {
+			deck, err := writeAnkiDeck(t, base)
+			paths = append(paths, deck...)
+			if err != nil {
+				return paths, err
+			}
+			continue
+		}
+
+		data, err := renderFormat(t, format, cfg)
+		if err != nil {
+			return paths, err
+		}
+		path := base + formatExtension(format)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return paths, fmt.Errorf("failed to write transcript: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
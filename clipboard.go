@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands lists the native clipboard tools to try, in order
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	}
+	return [][]string{
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	}
+}
+
+// copyToClipboard copies text using a native clipboard tool, falling back to
+// the OSC 52 escape sequence which most terminals (including over SSH) support
+func copyToClipboard(text string) error {
+	for _, command := range clipboardCommands() {
+		path, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, command[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(text))
+	if _, err := fmt.Fprintf(os.Stderr, "\x1b]52;c;%s\x07", encoded); err != nil {
+		return fmt.Errorf("failed to copy to clipboard: %w", err)
+	}
+	return nil
+}
+
+// copyTranscript copies the transcript text and returns a status message
+func (m model) copyTranscript() string {
+	if err := copyToClipboard(m.transcript.Text); err != nil {
+		return err.Error()
+	}
+	return tr("Copied transcript to clipboard")
+}
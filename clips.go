@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// cutClip extracts start to end seconds of the source's audio into path.
+// ffmpeg chooses the codec from the file extension unless encoding options
+// are given.
+func cutClip(ffmpegPath, source string, start, end float64, path string, encoding ...string) error {
+	if start < 0 {
+		start = 0
+	}
+	args := []string{
+		"-v", "error",
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-to", fmt.Sprintf("%.3f", end),
+		"-i", source,
+		"-vn",
+	}
+	args = append(args, encoding...)
+	cmd := exec.Command(ffmpegPath, append(args, path, "-y")...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg error: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// clipRange is a stretch of a recording to cut, with the text spoken in it
+type clipRange struct {
+	Start, End float64
+	Text       string
+}
+
+// matchingClips finds the segments containing query, ignoring case, and
+// widens each by context segments on either side. Overlapping or touching
+// ranges are merged into one clip.
+func matchingClips(segments []Segment, query string, context int) []clipRange {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+
+	var clips []clipRange
+	lastEnd := -1 // index of the last segment already in a clip
+	for i, seg := range segments {
+		if !strings.Contains(strings.ToLower(strings.Join(strings.Fields(seg.Text), " ")), query) {
+			continue
+		}
+		from := max(0, i-context)
+		to := min(len(segments)-1, i+context)
+
+		if len(clips) > 0 && from <= lastEnd+1 {
+			from = lastEnd + 1
+		} else {
+			clips = append(clips, clipRange{Start: segments[from].Start})
+		}
+		current := &clips[len(clips)-1]
+		for j := from; j <= to; j++ {
+			current.Text = strings.TrimSpace(current.Text + " " + segments[j].Text)
+			current.End = segments[j].End
+		}
+		lastEnd = max(lastEnd, to)
+	}
+	return clips
+}
+
+// clipPath names a clip after its input and start time
+func clipPath(input, outputDir string, start float64, format string) string {
+	stamp := strings.ReplaceAll(formatClock(start), ":", "")
+	return transcriptPath(input, outputDir, "-clip-"+stamp+"."+format)
+}
+
+// loadTranscriptJSON reads a transcript saved in the json format
+func loadTranscriptJSON(path string) (*Transcript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	var t Transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse transcript %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+// runClips cuts the parts of a recording where a phrase is spoken
+func runClips(args []string) error {
+	fs := flag.NewFlagSet("clips", flag.ContinueOnError)
+	query := fs.String("query", "", "phrase to look for (required)")
+	transcriptFile := fs.String("transcript", "", "use a transcript saved as json instead of transcribing")
+	context := fs.Int("context", 0, "segments to include before and after each match")
+	padding := fs.Duration("padding", 250*time.Millisecond, "extra audio to keep around each clip")
+	format := fs.String("clip-format", "mp3", "audio format of the clips, by file extension")
+	outputDir := fs.String("output-dir", "", "directory to write clips to")
+	cfg, err := addConfigFlags(fs)
+	if err != nil {
+		return err
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *query == "" || fs.NArg() != 1 {
+		return fmt.Errorf("usage: stt-cli clips --query PHRASE [flags] FILE")
+	}
+	path := fs.Arg(0)
+	if isRemoteInput(path) {
+		return fmt.Errorf("clips can only be cut from local files")
+	}
+	ffmpegPath, err := findFFmpeg()
+	if err != nil {
+		return err
+	}
+
+	var transcript *Transcript
+	if *transcriptFile != "" {
+		transcript, err = loadTranscriptJSON(*transcriptFile)
+	} else {
+		if err := cfg.validate(); err != nil {
+			return err
+		}
+		transcript, err = processAudioSTT(path, *cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	clips := matchingClips(transcript.Segments, *query, *context)
+	if len(clips) == 0 {
+		return fmt.Errorf("%q isn't spoken in %s", *query, path)
+	}
+	if *outputDir != "" {
+		if err := os.MkdirAll(*outputDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	pad := padding.Seconds()
+	for _, clip := range clips {
+		out := clipPath(path, *outputDir, clip.Start, *format)
+		if err := cutClip(ffmpegPath, path, clip.Start-pad, clip.End+pad, out); err != nil {
+			return err
+		}
+		fmt.Printf("[%s] %s\n  saved %s\n", formatClock(clip.Start), clip.Text, out)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// comparisonRun is one side of a model comparison
+type comparisonRun struct {
+	Label      string
+	Transcript *Transcript
+	Elapsed    time.Duration
+}
+
+// runCompare transcribes a file with two model/backend combinations and
+// prints the transcripts side by side with the differences highlighted
+func runCompare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	cfg, err := addConfigFlags(fs)
+	if err != nil {
+		return err
+	}
+	otherModel := fs.String("model-b", "", "model size for the second transcription (default: same as --model)")
+	otherBackend := fs.String("backend-b", "", "backend for the second transcription (default: same as --backend)")
+	width := fs.Int("width", 100, "total width of the side-by-side output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected exactly one input file")
+	}
+
+	other := *cfg
+	if *otherModel != "" {
+		other.Model = *otherModel
+	}
+	if *otherBackend != "" {
+		other.Backend = *otherBackend
+	}
+	if other.Model == cfg.Model && other.Backend == cfg.Backend {
+		return fmt.Errorf("both sides use %s with %s; pass --model-b or --backend-b", cfg.Model, cfg.Backend)
+	}
+	for _, c := range []*Config{cfg, &other} {
+		if err := c.validate(); err != nil {
+			return err
+		}
+	}
+
+	path := fs.Arg(0)
+	var runs []comparisonRun
+	for _, c := range []Config{*cfg, other} {
+		label := fmt.Sprintf("%s (%s)", c.Model, c.Backend)
+		fmt.Printf("Transcribing %s with %s\n", path, label)
+
+		start := time.Now()
+		transcript, err := processAudioSTT(path, c)
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+		runs = append(runs, comparisonRun{Label: label, Transcript: transcript, Elapsed: time.Since(start)})
+	}
+
+	fmt.Println()
+	fmt.Print(comparisonView(runs[0], runs[1], *width))
+	return nil
+}
+
+// comparisonView renders a summary of both runs followed by the transcripts
+// in two columns, with words only one side has highlighted
+func comparisonView(a, b comparisonRun, width int) string {
+	wordsA := strings.Fields(a.Transcript.Text)
+	wordsB := strings.Fields(b.Transcript.Text)
+	chunks := diffWords(wordsA, wordsB)
+
+	var sb strings.Builder
+	for _, run := range []comparisonRun{a, b} {
+		fmt.Fprintf(&sb, "%-28s %8s  %6d words\n",
+			run.Label, run.Elapsed.Round(time.Second), len(strings.Fields(run.Transcript.Text)))
+	}
+	fmt.Fprintf(&sb, "Word agreement: %.1f%%\n\n", wordAgreement(chunks)*100)
+
+	column := max(20, (width-3)/2)
+	fmt.Fprintf(&sb, "%s │ %s\n", padRight(titleStyle.Render(a.Label), column), titleStyle.Render(b.Label))
+	fmt.Fprintf(&sb, "%s─┼─%s\n", strings.Repeat("─", column), strings.Repeat("─", column))
+
+	// Shared runs fill both columns; a changed run shows what each side heard
+	for i := 0; i < len(chunks); i++ {
+		var left, right []string
+		if chunks[i].Op == diffEqual {
+			left = wrapWords(chunks[i].Words, lipgloss.NewStyle(), column)
+			right = left
+		} else {
+			var removed, added []string
+			for ; i < len(chunks) && chunks[i].Op != diffEqual; i++ {
+				if chunks[i].Op == diffDelete {
+					removed = append(removed, chunks[i].Words...)
+				} else {
+					added = append(added, chunks[i].Words...)
+				}
+			}
+			i--
+			left = wrapWords(removed, diffRemovedStyle, column)
+			right = wrapWords(added, diffAddedStyle, column)
+		}
+
+		for row := 0; row < max(len(left), len(right)); row++ {
+			var l, r string
+			if row < len(left) {
+				l = left[row]
+			}
+			if row < len(right) {
+				r = right[row]
+			}
+			fmt.Fprintf(&sb, "%s │ %s\n", padRight(l, column), r)
+		}
+	}
+	return sb.String()
+}
+
+// wrapWords wraps words into lines of at most width cells, rendering each
+// word with style
+func wrapWords(words []string, style lipgloss.Style, width int) []string {
+	var lines []string
+	var line []string
+	lineWidth := 0
+	for _, word := range words {
+		w := lipgloss.Width(word)
+		if len(line) > 0 && lineWidth+1+w > width {
+			lines = append(lines, strings.Join(line, " "))
+			line, lineWidth = nil, 0
+		}
+		if len(line) > 0 {
+			lineWidth++
+		}
+		line = append(line, style.Render(word))
+		lineWidth += w
+	}
+	if len(line) > 0 {
+		lines = append(lines, strings.Join(line, " "))
+	}
+	return lines
+}
+
+// padRight pads a possibly styled string with spaces to width cells
+func padRight(s string, width int) string {
+	if gap := width - lipgloss.Width(s); gap > 0 {
+		return s + strings.Repeat(" ", gap)
+	}
+	return s
+}
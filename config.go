@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Available choices for the user-facing settings
+var (
+	modelSizes = []string{"tiny", "base", "small", "medium", "large-v3"}
+	languages  = []string{"auto", "en", "es", "fr", "de", "it", "pt", "nl", "ja", "zh", "ru"}
+)
+
+// Config holds the user's persistent settings
+type Config struct {
+	Model        string `json:"model"`
+	Language     string `json:"language"`
+	OutputFormat string `json:"output_format"` // comma-separated list
+	Backend      string `json:"backend"`
+
+	// Translate produces an English transcript from speech in any language
+	Translate bool `json:"translate"`
+
+	// CodeSwitching detects the language of every segment and transcribes
+	// segments in other languages in their own language
+	CodeSwitching bool `json:"code_switching"`
+
+	// Diarize labels each segment with who is speaking
+	Diarize bool `json:"diarize"`
+
+	// Template is a text/template file used instead of OutputFormat
+	Template string `json:"template,omitempty"`
+
+	// Text formatting applied after transcription; see applyTextFormatting
+	FormatNumbers  bool `json:"format_numbers"`
+	FormatDates    bool `json:"format_dates"`
+	FormatCurrency bool `json:"format_currency"`
+	Capitalize     bool `json:"capitalize"`
+
+	// Analyze measures the tone of each segment and finds keywords
+	Analyze bool `json:"analyze"`
+
+	// Corrections is a find-and-replace file applied to every transcript
+	Corrections string `json:"corrections,omitempty"`
+
+	// BoostPhrases are names and terms the model should favour, passed to
+	// each backend in its own way; see BoostMethod
+	BoostPhrases []string `json:"boost_phrases,omitempty"`
+
+	// Audio preprocessing applied by ffmpeg before transcription
+	Denoise     bool `json:"denoise"`
+	Normalize   bool `json:"normalize"`
+	VoiceFilter bool `json:"voice_filter"`
+
+	// LowMemory limits the model size and decodes the audio in chunks,
+	// one file at a time, for machines with little memory
+	LowMemory bool `json:"low_memory,omitempty"`
+
+	// Words the model is unsure about are colored in the viewer and,
+	// when MarkLowConfidence is set, marked in exports
+	ConfidenceThreshold float64 `json:"confidence_threshold"`
+	MarkLowConfidence   bool    `json:"mark_low_confidence"`
+	LowConfidenceMarker string  `json:"low_confidence_marker"`
+
+	// Captions shapes segments into cues for the subtitle formats
+	Captions CaptionConfig `json:"captions"`
+
+	// Timestamps sets how times are shown in the viewer and written in
+	// formats that don't fix it, and the frame rate of the editor exports
+	Timestamps TimestampConfig `json:"timestamps"`
+
+	Theme ThemeConfig `json:"theme"`
+
+	// History sets how long job history is kept and whether the
+	// transcripts it keeps are encrypted
+	History HistoryConfig `json:"history"`
+
+	// UILanguage is the language of the interface, or auto for the locale's
+	UILanguage string `json:"ui_language"`
+
+	// Presets are named groups of settings, written like the config file
+	// itself, applied with --preset or from the preset chooser
+	Presets map[string]json.RawMessage `json:"presets,omitempty"`
+
+	// DisableUpdateCheck stops the TUI from looking for new releases
+	DisableUpdateCheck bool `json:"disable_update_check,omitempty"`
+
+	// LastDirectory is where the file browser opens
+	LastDirectory string `json:"last_directory,omitempty"`
+}
+
+// CaptionConfig holds the rules subtitle cues follow; 0 turns a rule off
+type CaptionConfig struct {
+	MaxLineLength int     `json:"max_line_length"` // characters
+	MaxLines      int     `json:"max_lines"`       // lines per cue
+	MaxDuration   float64 `json:"max_duration"`    // seconds a cue stays up
+	MinGap        float64 `json:"min_gap"`         // seconds between cues
+}
+
+// HistoryConfig protects the transcripts kept in the job store
+type HistoryConfig struct {
+	// RetentionDays removes jobs finished longer ago, with their kept
+	// transcripts; 0 keeps them forever
+	RetentionDays int `json:"retention_days,omitempty"`
+
+	// KeyFile holds the AES-256 key kept transcripts are encrypted with.
+	// It is created with a new key when missing.
+	KeyFile string `json:"key_file,omitempty"`
+}
+
+// defaultConfig returns the settings used when no config file exists
+func defaultConfig() Config {
+	return Config{
+		Model:        "base",
+		Language:     "auto",
+		OutputFormat: "txt",
+		Backend:      "whisper",
+		UILanguage:   "auto",
+
+		ConfidenceThreshold: 0.5,
+		LowConfidenceMarker: "(?)",
+
+		// Common broadcast guidelines; the gap is two frames at 25 fps
+		Captions: CaptionConfig{MaxLineLength: 42, MaxLines: 2, MaxDuration: 7, MinGap: 0.08},
+
+		Timestamps: TimestampConfig{Style: "clock", FrameRate: "25"},
+	}
+}
+
+// configPath returns the location of the config file
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "stt-cli", "config.json"), nil
+}
+
+// loadConfig reads the config file, falling back to defaults for a missing
+// file or missing fields
+func loadConfig() (Config, error) {
+	cfg := defaultConfig()
+
+	path, err := configPath()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return defaultConfig(), fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// save writes the config file, creating its directory if needed
+func (c Config) save() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// validate checks that the settings refer to known backends and formats
+func (c Config) validate() error {
+	if _, err := findBackend(c.Backend); err != nil {
+		return err
+	}
+	if len(c.formats()) == 0 {
+		return fmt.Errorf("no output format given")
+	}
+	for _, format := range c.formats() {
+		if !isOutputFormat(format) {
+			return fmt.Errorf("unknown output format %q", format)
+		}
+	}
+	if c.Template != "" {
+		if _, err := loadTemplate(c.Template); err != nil {
+			return err
+		}
+	}
+	if c.Corrections != "" {
+		if _, err := loadCorrections(c.Corrections); err != nil {
+			return err
+		}
+	}
+	if backend, _ := findBackend(c.Backend); len(c.BoostPhrases) > 0 && backend.Boost == BoostNone {
+		return fmt.Errorf("the %s backend doesn't support boost phrases", c.Backend)
+	}
+	if c.Captions.MaxLineLength < 0 || c.Captions.MaxLines < 0 || c.Captions.MaxDuration < 0 || c.Captions.MinGap < 0 {
+		return fmt.Errorf("caption rules can't be negative; use 0 to turn one off")
+	}
+	if err := c.Timestamps.validate(); err != nil {
+		return err
+	}
+	if c.History.RetentionDays < 0 {
+		return fmt.Errorf("history retention must be a number of days, not %d", c.History.RetentionDays)
+	}
+	return nil
+}
+
+// formats returns the output formats transcripts are saved in
+func (c Config) formats() []string {
+	var formats []string
+	for _, format := range strings.Split(c.OutputFormat, ",") {
+		if format = strings.TrimSpace(format); format != "" {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
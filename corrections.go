@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// correction is one find-and-replace rule from a corrections file
+type correction struct {
+	pattern     *regexp.Regexp
+	replacement string
+	literal     bool // replacement is used as is, without $1 expansion
+}
+
+// correctionSeparator divides the pattern from the replacement on a line
+const correctionSeparator = "=>"
+
+// loadCorrections reads a corrections file. Each line is
+//
+//	pattern => replacement
+//
+// where a pattern is a phrase matched as whole words regardless of case,
+// or a regular expression between slashes. Blank lines and lines starting
+// with # are ignored.
+func loadCorrections(path string) ([]correction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corrections: %w", err)
+	}
+	defer file.Close()
+
+	var rules []correction
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		i := strings.LastIndex(text, correctionSeparator)
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: expected \"pattern %s replacement\"", path, line, correctionSeparator)
+		}
+		pattern := strings.TrimSpace(text[:i])
+		replacement := strings.TrimSpace(text[i+len(correctionSeparator):])
+		if pattern == "" {
+			return nil, fmt.Errorf("%s:%d: empty pattern", path, line)
+		}
+
+		rule := correction{replacement: replacement, literal: true}
+		if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			rule.pattern, err = regexp.Compile(pattern[1 : len(pattern)-1])
+			rule.literal = false
+		} else {
+			rule.pattern, err = regexp.Compile(literalPattern(pattern))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read corrections: %w", err)
+	}
+	return rules, nil
+}
+
+// literalPattern matches a phrase case-insensitively as whole words, with
+// any run of spaces between its words
+func literalPattern(phrase string) string {
+	words := strings.Fields(phrase)
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	pattern := strings.Join(words, `\s+`)
+
+	// \b only works next to word characters, so "C++" can still match
+	isWordByte := func(b byte) bool {
+		return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+	}
+	if isWordByte(phrase[0]) {
+		pattern = `\b` + pattern
+	}
+	if isWordByte(phrase[len(phrase)-1]) {
+		pattern += `\b`
+	}
+	return `(?i)` + pattern
+}
+
+// applyCorrectionRules runs every rule over text in file order
+func applyCorrectionRules(text string, rules []correction) string {
+	for _, rule := range rules {
+		if rule.literal {
+			text = rule.pattern.ReplaceAllLiteralString(text, rule.replacement)
+		} else {
+			text = rule.pattern.ReplaceAllString(text, rule.replacement)
+		}
+	}
+	return text
+}
+
+// applyCorrections rewrites the transcript's segments and text. Rules are
+// applied per segment, so a phrase split across two segments isn't found.
+func applyCorrections(t *Transcript, rules []correction) {
+	if len(rules) == 0 {
+		return
+	}
+
+	texts := make([]string, len(t.Segments))
+	for i := range t.Segments {
+		t.Segments[i].Text = applyCorrectionRules(t.Segments[i].Text, rules)
+		texts[i] = t.Segments[i].Text
+	}
+	if len(texts) > 0 {
+		t.Text = strings.Join(texts, " ")
+	} else {
+		t.Text = applyCorrectionRules(t.Text, rules)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// demoSeconds is the length of the demo clip
+	demoSeconds = 24
+
+	// demoSampleRate matches the audio the backends are given
+	demoSampleRate = 16000
+)
+
+// demoClip returns a WAV file with a soft chime at the start of each of
+// the fake transcript's segments, so playback has something to play
+func demoClip() []byte {
+	samples := make([]int16, demoSeconds*demoSampleRate)
+	for _, seg := range fakeTranscript(demoSeconds).Segments {
+		start := int(seg.Start * demoSampleRate)
+		for i := 0; i < demoSampleRate/2 && start+i < len(samples); i++ {
+			t := float64(i) / demoSampleRate
+			fade := math.Exp(-6 * t)
+			tone := math.Sin(2*math.Pi*660*t) + 0.5*math.Sin(2*math.Pi*990*t)
+			samples[start+i] = int16(tone * fade * 6000)
+		}
+	}
+
+	size := uint32(len(samples) * 2)
+	header := struct {
+		Riff          [4]byte
+		Size          uint32
+		Wave, Fmt     [4]byte
+		FmtSize       uint32
+		Format        uint16 // PCM
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		[4]byte{'R', 'I', 'F', 'F'}, 36 + size, [4]byte{'W', 'A', 'V', 'E'}, [4]byte{'f', 'm', 't', ' '},
+		16, 1, 1, demoSampleRate, demoSampleRate * 2, 2, 16,
+		[4]byte{'d', 'a', 't', 'a'}, size,
+	}
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, header)
+	binary.Write(&b, binary.LittleEndian, samples)
+	return b.Bytes()
+}
+
+// writeDemoClip saves the demo clip in its own folder under the temp
+// directory, where transcripts saved from the demo end up too
+func writeDemoClip() (string, error) {
+	dir := filepath.Join(os.TempDir(), "stt-cli-demo")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create demo folder: %w", err)
+	}
+	path := filepath.Join(dir, "demo.wav")
+	if err := os.WriteFile(path, demoClip(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write demo clip: %w", err)
+	}
+	return path, nil
+}
+
+// runDemo transcribes a generated clip with the fake backend, going
+// through ffmpeg, Python and the results screen like a real file, so new
+// users can check their setup before finding a file of their own
+func runDemo(args []string) error {
+	fs := flag.NewFlagSet("demo", flag.ContinueOnError)
+	plain := fs.Bool("plain", false, "line-oriented output without the full-screen interface")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Warning: %v (using default settings)\n", err)
+	}
+	path, err := writeDemoClip()
+	if err != nil {
+		return err
+	}
+
+	if *plain || isDumbTerminal() {
+		cfg.Backend = fakeBackend.Name
+		transcribePlain(bufio.NewReader(os.Stdin), path, cfg)
+		return nil
+	}
+
+	m := initialModel(cfg)
+	m.selectedFile, m.demoFile = path, path
+	m.state = StateProcessing
+	finalModel, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion()).Run()
+	if err != nil {
+		return err
+	}
+	if m, ok := finalModel.(model); ok {
+		m.player.Stop()
+	}
+	fmt.Printf("The demo clip and anything saved from it are in %s\n", filepath.Dir(path))
+	return nil
+}
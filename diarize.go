@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pyannoteDiarizer is the Python package that tells speakers apart. Like
+// whisperxAligner, it only needs Module and Package for the dependency check.
+var pyannoteDiarizer = Backend{Name: "pyannote", Module: "pyannote.audio", Package: "pyannote.audio"}
+
+// diarizationModel is the pretrained pyannote pipeline. It is gated on
+// Hugging Face, so HF_TOKEN must hold a token for an account that accepted
+// its conditions.
+const diarizationModel = "pyannote/speaker-diarization-3.1"
+
+// speakerTurn is a stretch of audio where one speaker talks
+type speakerTurn struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Speaker string  `json:"speaker"`
+}
+
+// diarize finds who speaks when in the extracted audio and labels every
+// segment with its speaker, e.g. SPEAKER_00
+func (p *AudioProcessor) diarize(ctx context.Context, audioPath string, t *Transcript) error {
+	if os.Getenv("HF_TOKEN") == "" {
+		return fmt.Errorf("speaker diarization needs a Hugging Face token in HF_TOKEN")
+	}
+	if err := exec.CommandContext(ctx, p.PythonPath, "-c", "import "+pyannoteDiarizer.Module).Run(); err != nil {
+		cmd := exec.CommandContext(ctx, p.PythonPath, "-m", "pip", "install", pyannoteDiarizer.Package)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to install %s: %w", pyannoteDiarizer.Package, err)
+		}
+	}
+
+	turnsPath := filepath.Join(p.TempDir, "speakers.json")
+	scriptPath := filepath.Join(p.TempDir, "diarize.py")
+	if err := os.WriteFile(scriptPath, []byte(diarizationScript(audioPath, turnsPath)), 0644); err != nil {
+		return err
+	}
+
+	output, err := exec.CommandContext(ctx, p.PythonPath, scriptPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("python diarization error: %s", output)
+	}
+
+	data, err := os.ReadFile(turnsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read speakers: %w", err)
+	}
+	var turns []speakerTurn
+	if err := json.Unmarshal(data, &turns); err != nil {
+		return fmt.Errorf("failed to parse speakers: %w", err)
+	}
+	assignSpeakers(t.Segments, turns)
+	return nil
+}
+
+// assignSpeakers gives each segment the speaker who talks the most during
+// it. Segments no turn overlaps keep the speaker before them.
+func assignSpeakers(segments []Segment, turns []speakerTurn) {
+	previous := ""
+	for i, seg := range segments {
+		overlap := map[string]float64{}
+		for _, turn := range turns {
+			if d := math.Min(seg.End, turn.End) - math.Max(seg.Start, turn.Start); d > 0 {
+				overlap[turn.Speaker] += d
+			}
+		}
+
+		best, longest := previous, 0.0
+		for speaker, d := range overlap {
+			if d > longest || d == longest && speaker < best {
+				best, longest = speaker, d
+			}
+		}
+		segments[i].Speaker = best
+		previous = best
+	}
+}
+
+// diarizationScript builds the pyannote script. The extracted 16 kHz WAV is
+// passed in memory so pyannote doesn't need its own audio decoder.
+func diarizationScript(audioPath, outputPath string) string {
+	return fmt.Sprintf(`
+import json
+import os
+import wave
+
+import numpy as np
+import torch
+from pyannote.audio import Pipeline
+
+with wave.open(%s, "rb") as f:
+    audio = np.frombuffer(f.readframes(f.getnframes()), np.int16).astype(np.float32) / 32768.0
+
+print("Loading diarization model...")
+pipeline = Pipeline.from_pretrained(%q, use_auth_token=os.environ["HF_TOKEN"])
+if torch.cuda.is_available():
+    pipeline.to(torch.device("cuda"))
+
+print("Finding speakers...")
+diarization = pipeline({"waveform": torch.from_numpy(audio).unsqueeze(0), "sample_rate": 16000})
+
+turns = [
+    {"start": turn.start, "end": turn.end, "speaker": speaker}
+    for turn, _, speaker in diarization.itertracks(yield_label=True)
+]
+with open(%s, "w", encoding="utf-8") as f:
+    json.dump(turns, f)
+`, pythonPath(audioPath), diarizationModel, pythonPath(outputPath))
+}
+
+// speakers returns the transcript's speakers in the order they first talk
+func (t *Transcript) speakers() []string {
+	var speakers []string
+	seen := map[string]bool{}
+	for _, seg := range t.Segments {
+		if seg.Speaker != "" && !seen[seg.Speaker] {
+			seen[seg.Speaker] = true
+			speakers = append(speakers, seg.Speaker)
+		}
+	}
+	return speakers
+}
+
+// speakerSamples returns up to count of the speaker's longest segments in
+// the order they are spoken, to recognize the speaker by
+func (t *Transcript) speakerSamples(speaker string, count int) []Segment {
+	var samples []Segment
+	for _, seg := range t.Segments {
+		if seg.Speaker == speaker {
+			samples = append(samples, seg)
+		}
+	}
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].End-samples[i].Start > samples[j].End-samples[j].Start
+	})
+	if len(samples) > count {
+		samples = samples[:count]
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Start < samples[j].Start })
+	return samples
+}
+
+// renameSpeakers replaces speaker labels with the given names; labels
+// without a name, or with an empty one, are kept
+func (t *Transcript) renameSpeakers(names map[string]string) {
+	for i, seg := range t.Segments {
+		if name := strings.TrimSpace(names[seg.Speaker]); name != "" {
+			t.Segments[i].Speaker = name
+		}
+	}
+}
+
+// speakerText returns the transcript as one paragraph per speaker turn,
+// each starting with the speaker's name
+func (t *Transcript) speakerText() string {
+	var turns []string
+	speaker := ""
+	var texts []string
+	flush := func() {
+		if len(texts) == 0 {
+			return
+		}
+		turn := strings.Join(texts, " ")
+		if speaker != "" {
+			turn = speaker + ": " + turn
+		}
+		turns = append(turns, turn)
+		texts = nil
+	}
+	for _, seg := range t.Segments {
+		if seg.Speaker != speaker {
+			flush()
+			speaker = seg.Speaker
+		}
+		text := seg.Text
+		if seg.foreign(t) {
+			text = "[" + seg.Language + "] " + text
+		}
+		texts = append(texts, text)
+	}
+	flush()
+	return strings.Join(turns, "\n\n")
+}
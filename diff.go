@@ -0,0 +1,239 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// diffOp says whether a run of words is shared, only in the first
+// transcript, or only in the second
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffDelete
+	diffInsert
+)
+
+// diffChunk is a run of consecutive words with the same diff operation
+type diffChunk struct {
+	Op    diffOp
+	Words []string
+}
+
+// normalizeWord strips punctuation and case so that "Hello," and "hello"
+// count as the same word
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}))
+}
+
+// diffWords aligns two word sequences with Myers' algorithm. Words are
+// compared after normalization; chunks keep the original spelling, taken
+// from a for shared runs.
+func diffWords(a, b []string) []diffChunk {
+	keysA := make([]string, len(a))
+	for i, word := range a {
+		keysA[i] = normalizeWord(word)
+	}
+	keysB := make([]string, len(b))
+	for i, word := range b {
+		keysB[i] = normalizeWord(word)
+	}
+
+	n, m := len(a), len(b)
+	limit := n + m
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+
+	// trace[d] holds the furthest x for diagonals -d-1..d+1 before step d,
+	// which is all the backtracking below needs
+	var trace [][]int
+	found := n == 0 && m == 0
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && keysA[x] == keysB[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// Walk back from the end, collecting operations in reverse
+	type step struct {
+		op   diffOp
+		word string
+	}
+	var steps []step
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		at := func(k int) int { return trace[d][k+d+1] }
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			steps = append(steps, step{diffEqual, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				steps = append(steps, step{diffInsert, b[y-1]})
+			} else {
+				steps = append(steps, step{diffDelete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	var chunks []diffChunk
+	for i := len(steps) - 1; i >= 0; i-- {
+		s := steps[i]
+		if len(chunks) > 0 && chunks[len(chunks)-1].Op == s.op {
+			last := &chunks[len(chunks)-1]
+			last.Words = append(last.Words, s.word)
+			continue
+		}
+		chunks = append(chunks, diffChunk{Op: s.op, Words: []string{s.word}})
+	}
+	return chunks
+}
+
+// wordAgreement returns the share of words the two transcripts have in
+// common, relative to the longer one
+func wordAgreement(chunks []diffChunk) float64 {
+	var shared, deleted, inserted int
+	for _, chunk := range chunks {
+		switch chunk.Op {
+		case diffEqual:
+			shared += len(chunk.Words)
+		case diffDelete:
+			deleted += len(chunk.Words)
+		case diffInsert:
+			inserted += len(chunk.Words)
+		}
+	}
+
+	total := shared + max(deleted, inserted)
+	if total == 0 {
+		return 1
+	}
+	return float64(shared) / float64(total)
+}
+
+// errorCounts tallies the differences between a reference and a hypothesis
+type errorCounts struct {
+	Substitutions int
+	Deletions     int
+	Insertions    int
+	Reference     int // number of reference tokens
+}
+
+// errors returns the total number of errors
+func (c errorCounts) errors() int {
+	return c.Substitutions + c.Deletions + c.Insertions
+}
+
+// countErrors finds the fewest substitutions, deletions and insertions
+// that turn the reference into the hypothesis, by Levenshtein distance.
+// The diff used to display alignments minimizes changed runs instead and
+// can count more errors when words are reordered. Only two rows of the
+// table are kept, so long transcripts compared by character fit in memory.
+func countErrors(reference, hypothesis []string) errorCounts {
+	prev := make([]errorCounts, len(hypothesis)+1)
+	cur := make([]errorCounts, len(hypothesis)+1)
+	for j := range prev {
+		prev[j].Insertions = j
+	}
+	for i := 1; i <= len(reference); i++ {
+		cur[0] = errorCounts{Deletions: i}
+		for j := 1; j <= len(hypothesis); j++ {
+			best := prev[j-1]
+			if reference[i-1] != hypothesis[j-1] {
+				best.Substitutions++
+			}
+			if deleted := prev[j]; deleted.errors()+1 < best.errors() {
+				best = deleted
+				best.Deletions++
+			}
+			if inserted := cur[j-1]; inserted.errors()+1 < best.errors() {
+				best = inserted
+				best.Insertions++
+			}
+			cur[j] = best
+		}
+		prev, cur = cur, prev
+	}
+	counts := prev[len(hypothesis)]
+	counts.Reference = len(reference)
+	return counts
+}
+
+// rate returns the error rate: all errors divided by the reference length
+func (c errorCounts) rate() float64 {
+	errors := c.errors()
+	if c.Reference == 0 {
+		if errors == 0 {
+			return 0
+		}
+		return 1
+	}
+	return float64(errors) / float64(c.Reference)
+}
+
+// wordErrorRate compares a hypothesis with a reference transcript, ignoring
+// case and punctuation
+func wordErrorRate(reference, hypothesis string) float64 {
+	return countErrors(normalizedWords(reference), normalizedWords(hypothesis)).rate()
+}
+
+// characterErrorRate is like wordErrorRate but counts characters, which is
+// more forgiving of small spelling differences
+func characterErrorRate(reference, hypothesis string) float64 {
+	return countErrors(normalizedChars(reference), normalizedChars(hypothesis)).rate()
+}
+
+// normalizedWords splits text into normalized words, dropping tokens that
+// are only punctuation
+func normalizedWords(text string) []string {
+	var words []string
+	for _, field := range strings.Fields(text) {
+		if word := normalizeWord(field); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// normalizedChars splits the normalized words of text into characters,
+// keeping single spaces between words
+func normalizedChars(text string) []string {
+	var chars []string
+	for _, r := range strings.Join(normalizedWords(text), " ") {
+		chars = append(chars, string(r))
+	}
+	return chars
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestWordErrorRate(t *testing.T) {
+	tests := []struct {
+		reference, hypothesis string
+		want                  float64
+	}{
+		{"the cat sat", "the cat sat", 0},
+		{"The cat, sat.", "the cat sat", 0},
+		{"the cat sat", "the dog sat", 1.0 / 3},
+		{"the cat sat", "the sat", 1.0 / 3},
+		{"the cat sat", "the cat sat down", 1.0 / 3},
+		{"the cat sat", "", 1},
+		{"", "", 0},
+		{"", "hello", 1},
+
+		// A reordered word is one substitution each way, not a run of
+		// deletions and insertions
+		{"a b c d e", "e f g h a", 1},
+		{"a b c d", "b c d a", 0.5},
+	}
+	for _, test := range tests {
+		if got := wordErrorRate(test.reference, test.hypothesis); got != test.want {
+			t.Errorf("wordErrorRate(%q, %q) = %v, want %v", test.reference, test.hypothesis, got, test.want)
+		}
+	}
+}
+
+func TestCountErrors(t *testing.T) {
+	got := countErrors(normalizedWords("one two three four"), normalizedWords("one too three four five"))
+	want := errorCounts{Substitutions: 1, Insertions: 1, Reference: 4}
+	if got != want {
+		t.Errorf("countErrors = %+v, want %+v", got, want)
+	}
+}
+
+func TestCharacterErrorRate(t *testing.T) {
+	if got := characterErrorRate("kitten", "sitting"); got != 0.5 {
+		t.Errorf("characterErrorRate(kitten, sitting) = %v, want 0.5", got)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// whisperWindow is the length of audio Whisper decodes at a time
+const whisperWindow = 30.0
+
+// shellQuote quotes an argument for display in a POSIX shell command line
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~!") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// commandLine renders a command as it could be typed in a shell
+func commandLine(name string, args []string) string {
+	quoted := []string{shellQuote(name)}
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// outputPaths returns the files writeTranscripts would write for base
+func outputPaths(base string, cfg Config) []string {
+	if cfg.Template != "" {
+		return []string{base + templateExtension(cfg.Template)}
+	}
+	var paths []string
+	for _, format := range cfg.formats() {
+		if format == "anki" {
+			deck, media := ankiDeckPath(base)
+			paths = append(paths, deck, media+string(filepath.Separator))
+			continue
+		}
+		paths = append(paths, base+formatExtension(format))
+	}
+	return paths
+}
+
+// planPipeline prints what transcribing inputPath would do: the tools that
+// would run, the extraction command, the model and its passes over the
+// audio, the steps after transcription and the files written. The only
+// tool it runs is ffprobe, to read the input's length.
+func planPipeline(w io.Writer, inputPath string, opts batchOptions) {
+	cfg := opts.Config.withLowMemory()
+	detectLanguage := opts.DetectLanguage || opts.DetectOnly
+	backend, _ := pipelineBackend(cfg.Backend) // validated with the config
+	tools := &AudioProcessor{InputPath: inputPath, Config: cfg, Backend: backend}
+
+	fmt.Fprintln(w, "  Tools")
+	ffmpegPath, err := findFFmpeg()
+	if err != nil {
+		fmt.Fprintf(w, "    ffmpeg    missing: %v\n", err)
+		ffmpegPath = "ffmpeg"
+	} else {
+		fmt.Fprintf(w, "    ffmpeg    %s\n", ffmpegPath)
+	}
+	tools.FFmpegPath = ffmpegPath
+	if tools.FFprobePath = findFFprobe(ffmpegPath); tools.FFprobePath != "" {
+		fmt.Fprintf(w, "    ffprobe   %s\n", tools.FFprobePath)
+	} else {
+		fmt.Fprintln(w, "    ffprobe   missing: inputs are checked by extension only")
+	}
+	if pythonPath, err := exec.LookPath("python"); err == nil {
+		fmt.Fprintf(w, "    python    %s\n", pythonPath)
+	} else {
+		fmt.Fprintln(w, "    python    missing: python not found in PATH")
+	}
+
+	fmt.Fprintln(w, "  Input")
+	var media *MediaInfo
+	if isRemoteInput(inputPath) {
+		fmt.Fprintf(w, "    download  %s into a temporary directory\n", inputPath)
+	} else if _, err := os.Stat(inputPath); err != nil {
+		fmt.Fprintf(w, "    error     cannot read input file: %v\n", err)
+	} else if tools.FFprobePath == "" {
+		if !isSupportedExtension(inputPath) {
+			fmt.Fprintf(w, "    error     unsupported file type %q\n", filepath.Ext(inputPath))
+		} else {
+			fmt.Fprintf(w, "    file      %s\n", inputPath)
+		}
+	} else if media, err = probeMedia(tools.FFprobePath, inputPath); err != nil {
+		fmt.Fprintf(w, "    error     not a recognized media file: %v\n", err)
+	} else if !media.HasAudio() {
+		fmt.Fprintln(w, "    error     no audio stream")
+	} else {
+		fmt.Fprintf(w, "    media     %s, %s, %s %d Hz, %d channels\n",
+			media.FormatName, formatDuration(media.Duration), media.AudioCodecs[0], media.SampleRate, media.Channels)
+	}
+
+	fmt.Fprintln(w, "  Extraction")
+	audioPath := filepath.Join(os.TempDir(), "audio_stt-*", "audio.wav")
+	fmt.Fprintf(w, "    %s\n", commandLine(ffmpegPath, tools.extractArgs(audioPath)))
+
+	fmt.Fprintln(w, "  Transcription")
+	fmt.Fprintf(w, "    backend   %s (Python module %s, pip install %s if missing)\n", backend.Name, backend.Module, backend.Package)
+	language := cfg.Language
+	if language == "auto" {
+		language = fmt.Sprintf("detected from the first %.0f seconds", whisperWindow)
+	}
+	fmt.Fprintf(w, "    model     %s, language %s\n", cfg.Model, language)
+	if cfg.LowMemory && cfg.Model != opts.Config.Model {
+		fmt.Fprintf(w, "    memory    low: %s swapped for %s\n", opts.Config.Model, cfg.Model)
+	}
+	if cfg.Translate {
+		fmt.Fprintln(w, "    task      translate to English")
+	}
+	if len(cfg.BoostPhrases) > 0 {
+		fmt.Fprintf(w, "    boost     %s (as %s)\n", strings.Join(cfg.BoostPhrases, ", "), backend.Boost)
+	}
+	if cfg.LowMemory {
+		fmt.Fprintf(w, "    chunking  read and decoded %.0f seconds at a time, segments written to disk as they finish\n", lowMemoryChunk)
+	} else if media != nil && media.Duration > 0 {
+		windows := int(media.Duration/whisperWindow) + 1
+		fmt.Fprintf(w, "    chunking  one pass over %s of audio, decoded in %d windows of %.0f seconds\n",
+			formatDuration(media.Duration), windows, whisperWindow)
+	} else {
+		fmt.Fprintf(w, "    chunking  one pass over the whole file, decoded in windows of %.0f seconds\n", whisperWindow)
+	}
+	if detectLanguage && media != nil && media.Duration > 0 {
+		var starts []string
+		for _, window := range sampleWindows(media.Duration, detectionSamples) {
+			starts = append(starts, formatClock(window.Start))
+		}
+		fmt.Fprintf(w, "    detection %d windows of %.0f seconds at %s, before transcribing\n",
+			len(starts), languageWindow, strings.Join(starts, ", "))
+	} else if detectLanguage {
+		fmt.Fprintf(w, "    detection %d windows of %.0f seconds spread over the file, before transcribing\n",
+			detectionSamples, languageWindow)
+	}
+
+	var steps []string
+	if cfg.CodeSwitching {
+		steps = append(steps, fmt.Sprintf("detect the language of each segment over %.0f seconds long", minLanguageSegment))
+	}
+	if cfg.Diarize {
+		token := "HF_TOKEN is set"
+		if os.Getenv("HF_TOKEN") == "" {
+			token = "HF_TOKEN is not set, so this step would fail"
+		}
+		steps = append(steps, fmt.Sprintf("identify speakers with %s (%s)", diarizationModel, token))
+	}
+	var formatting []string
+	for _, rule := range []struct {
+		on   bool
+		name string
+	}{{cfg.FormatNumbers, "numbers"}, {cfg.FormatDates, "dates"}, {cfg.FormatCurrency, "currency"}, {cfg.Capitalize, "capitalization"}} {
+		if rule.on {
+			formatting = append(formatting, rule.name)
+		}
+	}
+	if len(formatting) > 0 {
+		steps = append(steps, "format "+strings.Join(formatting, ", "))
+	}
+	if cfg.Corrections != "" {
+		steps = append(steps, "apply corrections from "+cfg.Corrections)
+	}
+	if cfg.Analyze {
+		steps = append(steps, "measure tone and find keywords")
+	}
+	if cfg.MarkLowConfidence {
+		steps = append(steps, fmt.Sprintf("mark words below %.0f%% confidence with %s", cfg.ConfidenceThreshold*100, cfg.LowConfidenceMarker))
+	}
+	for _, format := range cfg.formats() {
+		if isCaptionFormat(format) && cfg.Template == "" {
+			steps = append(steps, "shape captions: "+cfg.Captions.String())
+			break
+		}
+	}
+	if len(steps) > 0 {
+		fmt.Fprintln(w, "  After transcription")
+		for _, step := range steps {
+			fmt.Fprintf(w, "    %s\n", step)
+		}
+	}
+
+	fmt.Fprintln(w, "  Output")
+	switch {
+	case opts.DetectOnly:
+		fmt.Fprintln(w, "    none, languages only")
+	case opts.Merge:
+		fmt.Fprintln(w, "    none, merged with the other files")
+	case opts.Output == "-":
+		fmt.Fprintln(w, "    standard output")
+	case opts.Output != "":
+		fmt.Fprintf(w, "    %s\n", opts.Output)
+	default:
+		for _, path := range outputPaths(transcriptPath(inputPath, opts.OutputDir, ""), cfg) {
+			fmt.Fprintf(w, "    %s\n", path)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// markerNameWidth is the longest marker name; the text is in the comment
+const markerNameWidth = 32
+
+// markerText puts a segment on one line for a marker, with its speaker
+func markerText(seg Segment) string {
+	text := strings.Join(strings.Fields(seg.Text), " ")
+	if seg.Speaker != "" {
+		text = seg.Speaker + ": " + text
+	}
+	return text
+}
+
+// markerFrames returns a segment's first and last frames, at least one
+// frame apart
+func markerFrames(seg Segment, r frameRate) (int, int) {
+	in, out := r.frames(seg.Start), r.frames(seg.End)
+	return in, max(out, in+1)
+}
+
+// formatEDL renders segments as a CMX 3600 edit decision list of markers,
+// one event per segment, which DaVinci Resolve imports onto a timeline
+// with Import > Timeline Markers from EDL. The |M: note is the marker's
+// name and |D: its length in frames.
+func formatEDL(t *Transcript, r frameRate) string {
+	var b strings.Builder
+	title := "Transcript"
+	if t.Source != "" {
+		title = filepath.Base(t.Source)
+	}
+	fmt.Fprintf(&b, "TITLE: %s\nFCM: %s\n\n", title, r.dropFrameName())
+	for i, seg := range t.Segments {
+		in, out := markerFrames(seg, r)
+		// Marker notes end at the next bar
+		name := strings.ReplaceAll(markerText(seg), "|", "/")
+		fmt.Fprintf(&b, "%03d  001      V     C        %s %s %s %s  \n",
+			i+1, r.timecode(in), r.timecode(out), r.timecode(in), r.timecode(out))
+		fmt.Fprintf(&b, " |C:ResolveColorBlue |M:%s |D:%d\n\n", name, out-in)
+	}
+	return b.String()
+}
+
+// formatMarkers renders segments as markers on an empty sequence in Final
+// Cut Pro 7 XML, which Premiere Pro and DaVinci Resolve both import. Each
+// marker is named with the segment's first words and has its whole text
+// as the comment.
+func formatMarkers(t *Transcript, r frameRate) string {
+	name := "Transcript"
+	if t.Source != "" {
+		name = filepath.Base(t.Source)
+	}
+	ntsc := "FALSE"
+	if r.ntsc() {
+		ntsc = "TRUE"
+	}
+	display := "NDF"
+	if r.drop {
+		display = "DF"
+	}
+	rate := fmt.Sprintf("<rate><timebase>%d</timebase><ntsc>%s</ntsc></rate>", r.nominal, ntsc)
+
+	length := t.Duration
+	if length <= 0 {
+		length = t.duration()
+	}
+
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!DOCTYPE xmeml>\n<xmeml version=\"4\">\n")
+	b.WriteString("  <sequence>\n")
+	fmt.Fprintf(&b, "    <name>%s</name>\n", xmlText(name))
+	fmt.Fprintf(&b, "    <duration>%d</duration>\n", r.frames(length))
+	fmt.Fprintf(&b, "    %s\n", rate)
+	fmt.Fprintf(&b, "    <timecode>%s<string>%s</string><frame>0</frame><displayformat>%s</displayformat></timecode>\n",
+		rate, r.timecode(0), display)
+	b.WriteString("    <media><video><track/></video><audio><track/></audio></media>\n")
+	for _, seg := range t.Segments {
+		in, out := markerFrames(seg, r)
+		text := markerText(seg)
+		b.WriteString("    <marker>\n")
+		fmt.Fprintf(&b, "      <name>%s</name>\n", xmlText(truncate(text, markerNameWidth)))
+		fmt.Fprintf(&b, "      <comment>%s</comment>\n", xmlText(text))
+		fmt.Fprintf(&b, "      <in>%d</in>\n      <out>%d</out>\n", in, out)
+		b.WriteString("    </marker>\n")
+	}
+	b.WriteString("  </sequence>\n</xmeml>\n")
+	return b.String()
+}
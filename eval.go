@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// runEval transcribes a file and scores the result against a ground-truth
+// transcript, printing WER, CER and the word alignment
+func runEval(args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	cfg, err := addConfigFlags(fs)
+	if err != nil {
+		return err
+	}
+	refPath := fs.String("ref", "", "reference transcript (plain text)")
+	width := fs.Int("width", 100, "width of the alignment output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *refPath == "" {
+		return fmt.Errorf("--ref is required")
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected exactly one input file")
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*refPath)
+	if err != nil {
+		return fmt.Errorf("failed to read reference transcript: %w", err)
+	}
+	reference := string(data)
+
+	transcript, err := processAudioSTTWithProgress(fs.Arg(0), *cfg, func(stage string) {
+		fmt.Printf("Status: %s\n", stage)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Print(evalReport(reference, transcript.Text, *width))
+	return nil
+}
+
+// evalReport renders the error rates followed by the alignment of the
+// reference against the hypothesis
+func evalReport(reference, hypothesis string, width int) string {
+	refWords, hypWords := normalizedWords(reference), normalizedWords(hypothesis)
+	chunks := diffWords(refWords, hypWords)
+	words := countErrors(refWords, hypWords)
+	chars := countErrors(normalizedChars(reference), normalizedChars(hypothesis))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Reference words: %d\n", words.Reference)
+	fmt.Fprintf(&sb, "WER: %.2f%% (%d substitutions, %d deletions, %d insertions)\n",
+		words.rate()*100, words.Substitutions, words.Deletions, words.Insertions)
+	fmt.Fprintf(&sb, "CER: %.2f%% (%d substitutions, %d deletions, %d insertions)\n",
+		chars.rate()*100, chars.Substitutions, chars.Deletions, chars.Insertions)
+
+	fmt.Fprintf(&sb, "\nAlignment: [-missing-] {+extra+} [reference→heard]\n\n")
+	for _, line := range wrapWords(alignmentTokens(chunks), lipgloss.NewStyle(), width) {
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+// alignmentTokens turns a diff into display tokens: shared words as is,
+// substitutions paired up and leftover deletions and insertions marked
+func alignmentTokens(chunks []diffChunk) []string {
+	var tokens []string
+	for i := 0; i < len(chunks); i++ {
+		if chunks[i].Op == diffEqual {
+			tokens = append(tokens, chunks[i].Words...)
+			continue
+		}
+
+		var removed, added []string
+		for ; i < len(chunks) && chunks[i].Op != diffEqual; i++ {
+			if chunks[i].Op == diffDelete {
+				removed = append(removed, chunks[i].Words...)
+			} else {
+				added = append(added, chunks[i].Words...)
+			}
+		}
+		i--
+
+		for j := 0; j < max(len(removed), len(added)); j++ {
+			switch {
+			case j < len(removed) && j < len(added):
+				tokens = append(tokens, "["+diffRemovedStyle.Render(removed[j])+"→"+diffAddedStyle.Render(added[j])+"]")
+			case j < len(removed):
+				tokens = append(tokens, diffRemovedStyle.Render("[-"+removed[j]+"-]"))
+			default:
+				tokens = append(tokens, diffAddedStyle.Render("{+"+added[j]+"+}"))
+			}
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openExport switches to the export menu, starting from the configured formats
+func (m model) openExport() model {
+	m.prevState = m.state
+	m.state = StateExport
+	m.exportFormats = m.config.OutputFormat
+	m.status = ""
+	return m
+}
+
+// formatSelected reports whether format is in the comma-separated list
+func formatSelected(list, format string) bool {
+	for _, f := range strings.Split(list, ",") {
+		if strings.TrimSpace(f) == format {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleFormat adds or removes format from the list, keeping the order of
+// outputFormats
+func toggleFormat(list, format string) string {
+	var formats []string
+	for _, f := range outputFormats {
+		selected := formatSelected(list, f)
+		if f == format {
+			selected = !selected
+		}
+		if selected {
+			formats = append(formats, f)
+		}
+	}
+	return strings.Join(formats, ",")
+}
+
+// updateExport handles key presses on the export menu
+func (m model) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.ForceQuit):
+		return m, tea.Quit
+	case key.Matches(msg, keys.Close):
+		m.state = m.prevState
+	case key.Matches(msg, keys.Up):
+		if m.exportCursor > 0 {
+			m.exportCursor--
+		}
+	case key.Matches(msg, keys.Down):
+		if m.exportCursor < len(outputFormats)-1 {
+			m.exportCursor++
+		}
+	case key.Matches(msg, keys.ToggleFormat):
+		m.exportFormats = toggleFormat(m.exportFormats, outputFormats[m.exportCursor])
+	case key.Matches(msg, keys.Export):
+		if m.exportFormats == "" && m.config.Template == "" {
+			m.status = tr("Select at least one format")
+			return m, nil
+		}
+
+		// Remember the selection for the next export
+		if m.exportFormats != "" && m.exportFormats != m.config.OutputFormat {
+			m.config.OutputFormat = m.exportFormats
+			m.config.save()
+		}
+		m.state = m.prevState
+		m.status = m.saveTranscript()
+	}
+	return m, nil
+}
+
+// exportView renders the export menu
+func (m model) exportView() string {
+	var rows []string
+	for i, format := range outputFormats {
+		check := "[ ]"
+		if formatSelected(m.exportFormats, format) {
+			check = "[x]"
+		}
+
+		row := fmt.Sprintf("  %s %s", check, format)
+		if i == m.exportCursor {
+			row = successStyle.Render(fmt.Sprintf("> %s %s", check, format))
+		}
+		rows = append(rows, row)
+	}
+
+	status := subtitleStyle.Render(tr("↑/↓ to move • Space to select • Enter to save • Esc to go back"))
+	if m.config.Template != "" {
+		status = subtitleStyle.Render(tr("The template %s replaces these formats", filepath.Base(m.config.Template))) +
+			"\n" + status
+	}
+	if m.status != "" {
+		status = errorStyle.Render(m.status) + "\n" + status
+	}
+
+	return fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s",
+		titleStyle.Render("Speech-to-Text CLI"),
+		subtitleStyle.Render(tr("Save transcript as")),
+		strings.Join(rows, "\n"),
+		status)
+}
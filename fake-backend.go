@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// fakeBackend returns a fixed transcript instead of recognizing speech, so
+// the rest of the pipeline, ffmpeg and Python included, can run without
+// downloading a model. It drives the demo and tests and isn't offered in
+// the settings.
+var fakeBackend = Backend{
+	Name:    "fake",
+	Module:  "json", // part of Python itself, so nothing is installed
+	Package: "json",
+	Script:  fakeScript,
+
+	DetectScript: fakeDetectScript,
+}
+
+// fakeSentences is the transcript the fake backend produces, spread over
+// the length of the audio
+var fakeSentences = []string{
+	"Welcome to the speech to text demo.",
+	"This clip was transcribed by a stand-in for the speech model, so nothing had to be downloaded.",
+	"Everything else ran for real: ffmpeg extracted the audio and Python produced these segments.",
+	"Use the brackets to move between segments and the space bar to play them.",
+	"Words the model is unsure about, like ffmpeg, are colored.",
+	"Press s to save the transcript, or n to pick one of your own files.",
+}
+
+// fakeUnsureWord is given a low probability to show confidence coloring
+const fakeUnsureWord = "ffmpeg,"
+
+// fakeTranscript spreads fakeSentences over duration seconds, giving each
+// sentence time in proportion to its words
+func fakeTranscript(duration float64) Transcript {
+	total := 0
+	for _, sentence := range fakeSentences {
+		total += len(strings.Fields(sentence))
+	}
+
+	t := Transcript{Text: strings.Join(fakeSentences, " "), Language: "en"}
+	start := 0.0
+	for _, sentence := range fakeSentences {
+		words := strings.Fields(sentence)
+		length := duration * float64(len(words)) / float64(total)
+		seg := Segment{Start: start, End: start + length, Text: sentence}
+		step := length / float64(len(words))
+		for i, word := range words {
+			probability := 0.95
+			if word == fakeUnsureWord {
+				probability = 0.3
+			}
+			seg.Words = append(seg.Words, Word{
+				Start:       start + float64(i)*step,
+				End:         start + float64(i+1)*step,
+				Text:        word,
+				Probability: probability,
+			})
+		}
+		t.Segments = append(t.Segments, seg)
+		start += length
+	}
+	return t
+}
+
+// fakeScript builds a script that streams the fake transcript a segment at
+// a time, as a model would
+func fakeScript(audioPath, outputPath string, cfg Config) string {
+	duration, err := wavDuration(audioPath)
+	if err != nil || duration <= 0 {
+		duration = float64(len(fakeSentences)) * 4
+	}
+	data, _ := json.Marshal(fakeTranscript(duration))
+
+	return fmt.Sprintf(`
+import json
+import time
+
+output = json.loads(%q)
+for segment in output["segments"]:
+    time.sleep(0.4)
+    print(%q + json.dumps(segment), flush=True)
+
+with open(%s, "w", encoding="utf-8") as f:
+    json.dump(output, f, ensure_ascii=False)
+`, string(data), segmentLinePrefix, pythonPath(outputPath))
+}
+
+// fakeDetectScript builds a script that hears English in every window
+func fakeDetectScript(audioPath, windowsPath, outputPath string, cfg Config) string {
+	return fmt.Sprintf(`
+import json
+
+with open(%s, encoding="utf-8") as f:
+    windows = json.load(f)
+for w in windows:
+    w["language"] = "en"
+    w["probability"] = 1.0
+
+with open(%s, "w", encoding="utf-8") as f:
+    json.dump(windows, f)
+`, pythonPath(windowsPath), pythonPath(outputPath))
+}
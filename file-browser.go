@@ -0,0 +1,479 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Sort orders for the file browser
+const (
+	SortByName = iota
+	SortByDate
+	SortBySize
+)
+
+var sortNames = []string{"name", "date", "size"}
+
+// browserEntry is one file or directory in the browser listing
+type browserEntry struct {
+	Name    string
+	Path    string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+// dirLoadedMsg carries the contents of a directory read in the background
+type dirLoadedMsg struct {
+	dir     string
+	entries []browserEntry
+	err     error
+}
+
+// durationMsg carries the probed duration of a media file
+type durationMsg struct {
+	path     string
+	duration float64
+}
+
+// fileBrowser lists directories and supported media files, with filtering,
+// sorting, a hidden-file toggle, size/duration columns and a preview pane
+type fileBrowser struct {
+	Dir         string
+	Width       int
+	Height      int
+	entries     []browserEntry
+	visible     []browserEntry
+	cursor      int
+	offset      int
+	showHidden  bool
+	sortMode    int
+	filter      textinput.Model
+	durations   map[string]float64
+	probing     map[string]bool
+	previews    map[string]filePreview
+	previewPath string
+	ffmpegPath  string
+	ffprobePath string
+	err         error
+	selected    string
+}
+
+// newFileBrowser creates a browser rooted at dir sized for the terminal height
+func newFileBrowser(dir string, height int) fileBrowser {
+	filter := textinput.New()
+	filter.Prompt = tr("Filter: ")
+	filter.Placeholder = tr("type to fuzzy-match file names")
+	filter.CharLimit = 256
+
+	ffmpegPath, _ := findFFmpeg()
+
+	return fileBrowser{
+		Dir:         dir,
+		Height:      browserHeight(height),
+		filter:      filter,
+		durations:   make(map[string]float64),
+		probing:     make(map[string]bool),
+		previews:    make(map[string]filePreview),
+		ffmpegPath:  ffmpegPath,
+		ffprobePath: findFFprobe(ffmpegPath),
+	}
+}
+
+// browserHeight returns how many rows the listing gets for a terminal height
+func browserHeight(height int) int {
+	// Leave space for title, subtitle, header, filter and path input
+	return max(3, height-10)
+}
+
+func (b fileBrowser) Init() tea.Cmd {
+	return readDirCmd(b.Dir)
+}
+
+// readDirCmd lists a directory in the background
+func readDirCmd(dir string) tea.Cmd {
+	return func() tea.Msg {
+		dirEntries, err := os.ReadDir(dir)
+		if err != nil {
+			return dirLoadedMsg{dir: dir, err: err}
+		}
+
+		var entries []browserEntry
+		for _, dirEntry := range dirEntries {
+			info, err := dirEntry.Info()
+			if err != nil {
+				continue
+			}
+			path := filepath.Join(dir, dirEntry.Name())
+
+			// Follow symlinks so linked folders can be opened
+			isDir := info.IsDir()
+			if info.Mode()&os.ModeSymlink != 0 {
+				if target, err := os.Stat(path); err == nil {
+					isDir = target.IsDir()
+				}
+			}
+
+			if !isDir && !isSupportedExtension(dirEntry.Name()) {
+				continue
+			}
+			entries = append(entries, browserEntry{
+				Name:    dirEntry.Name(),
+				Path:    path,
+				IsDir:   isDir,
+				Size:    info.Size(),
+				ModTime: info.ModTime(),
+			})
+		}
+		return dirLoadedMsg{dir: dir, entries: entries}
+	}
+}
+
+// probeDurationCmd looks up the duration of a media file with ffprobe
+func probeDurationCmd(ffprobePath, path string) tea.Cmd {
+	return func() tea.Msg {
+		info, err := probeMedia(ffprobePath, path)
+		if err != nil {
+			return durationMsg{path: path, duration: -1}
+		}
+		return durationMsg{path: path, duration: info.Duration}
+	}
+}
+
+// Filtering reports whether the filter field has focus
+func (b fileBrowser) Filtering() bool {
+	return b.filter.Focused()
+}
+
+// Highlighted returns the entry under the cursor, if any
+func (b fileBrowser) Highlighted() (browserEntry, bool) {
+	if b.cursor < 0 || b.cursor >= len(b.visible) {
+		return browserEntry{}, false
+	}
+	return b.visible[b.cursor], true
+}
+
+// DidSelectFile returns the chosen file once, clearing the selection
+func (b *fileBrowser) DidSelectFile() (string, bool) {
+	if b.selected == "" {
+		return "", false
+	}
+	path := b.selected
+	b.selected = ""
+	return path, true
+}
+
+// fuzzyMatch reports whether all characters of pattern appear in name in order
+func fuzzyMatch(pattern, name string) bool {
+	pattern = strings.ToLower(pattern)
+	name = strings.ToLower(name)
+
+	i := 0
+	for _, r := range name {
+		if i < len(pattern) && rune(pattern[i]) == r {
+			i++
+		}
+	}
+	return i >= len(pattern)
+}
+
+// refresh rebuilds the visible listing from the filter, hidden-file toggle
+// and sort order
+func (b fileBrowser) refresh() fileBrowser {
+	pattern := strings.TrimSpace(b.filter.Value())
+
+	b.visible = b.visible[:0]
+	for _, entry := range b.entries {
+		if !b.showHidden && strings.HasPrefix(entry.Name, ".") {
+			continue
+		}
+		if pattern != "" && !fuzzyMatch(pattern, entry.Name) {
+			continue
+		}
+		b.visible = append(b.visible, entry)
+	}
+
+	sort.SliceStable(b.visible, func(i, j int) bool {
+		a, c := b.visible[i], b.visible[j]
+		if a.IsDir != c.IsDir {
+			return a.IsDir // directories first
+		}
+		switch b.sortMode {
+		case SortByDate:
+			return a.ModTime.After(c.ModTime)
+		case SortBySize:
+			return a.Size > c.Size
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(c.Name)
+	})
+
+	b.cursor = min(b.cursor, max(0, len(b.visible)-1))
+	return b.clampOffset()
+}
+
+// clampOffset keeps the cursor inside the visible window
+func (b fileBrowser) clampOffset() fileBrowser {
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	} else if b.cursor >= b.offset+b.Height {
+		b.offset = b.cursor - b.Height + 1
+	}
+	return b
+}
+
+// probeVisible starts duration lookups for on-screen media files
+func (b fileBrowser) probeVisible() (fileBrowser, tea.Cmd) {
+	if b.ffprobePath == "" {
+		return b, nil
+	}
+
+	var cmds []tea.Cmd
+	end := min(b.offset+b.Height, len(b.visible))
+	for _, entry := range b.visible[b.offset:end] {
+		if entry.IsDir || b.probing[entry.Path] {
+			continue
+		}
+		if _, ok := b.durations[entry.Path]; ok {
+			continue
+		}
+		b.probing[entry.Path] = true
+		cmds = append(cmds, probeDurationCmd(b.ffprobePath, entry.Path))
+	}
+	return b, tea.Batch(cmds...)
+}
+
+// open changes into a directory
+func (b fileBrowser) open(dir string) (fileBrowser, tea.Cmd) {
+	b.Dir = dir
+	b.cursor = 0
+	b.offset = 0
+	b.filter.Reset()
+	b.filter.Blur()
+	return b, readDirCmd(dir)
+}
+
+func (b fileBrowser) Update(msg tea.Msg) (fileBrowser, tea.Cmd) {
+	switch msg := msg.(type) {
+	case previewRequestMsg:
+		// Only probe if the cursor is still on the same file
+		if msg.path != b.previewPath || b.ffprobePath == "" {
+			return b, nil
+		}
+		if _, ok := b.previews[msg.path]; ok {
+			return b, nil
+		}
+		return b, previewCmd(b.ffmpegPath, b.ffprobePath, msg.path)
+
+	case previewMsg:
+		b.previews[msg.path] = msg.preview
+		return b, nil
+	}
+
+	b, cmd := b.update(msg)
+
+	// Queue a preview when the cursor lands on a different file
+	if entry, ok := b.Highlighted(); ok && !entry.IsDir && entry.Path != b.previewPath {
+		b.previewPath = entry.Path
+		cmd = tea.Batch(cmd, schedulePreview(entry.Path))
+	}
+	return b, cmd
+}
+
+// update handles listing results and key presses
+func (b fileBrowser) update(msg tea.Msg) (fileBrowser, tea.Cmd) {
+	switch msg := msg.(type) {
+	case dirLoadedMsg:
+		if msg.dir != b.Dir {
+			return b, nil
+		}
+		b.err = msg.err
+		b.entries = msg.entries
+		b = b.refresh()
+		return b.probeVisible()
+
+	case durationMsg:
+		delete(b.probing, msg.path)
+		b.durations[msg.path] = msg.duration
+		return b, nil
+
+	case tea.KeyMsg:
+		if b.filter.Focused() {
+			return b.updateFilter(msg)
+		}
+
+		switch {
+		case key.Matches(msg, keys.Up):
+			b.cursor = max(0, b.cursor-1)
+		case key.Matches(msg, keys.Down):
+			b.cursor = min(max(0, len(b.visible)-1), b.cursor+1)
+		case msg.String() == "pgup":
+			b.cursor = max(0, b.cursor-b.Height)
+		case msg.String() == "pgdown":
+			b.cursor = min(max(0, len(b.visible)-1), b.cursor+b.Height)
+		case key.Matches(msg, keys.Top), msg.String() == "g":
+			b.cursor = 0
+		case key.Matches(msg, keys.Bottom), msg.String() == "G":
+			b.cursor = max(0, len(b.visible)-1)
+		case key.Matches(msg, keys.Back):
+			parent := filepath.Dir(b.Dir)
+			if parent != b.Dir {
+				return b.open(parent)
+			}
+		case key.Matches(msg, keys.Open):
+			if entry, ok := b.Highlighted(); ok {
+				if entry.IsDir {
+					return b.open(entry.Path)
+				}
+				b.selected = entry.Path
+			}
+		case key.Matches(msg, keys.ToggleHidden):
+			b.showHidden = !b.showHidden
+			b = b.refresh()
+		case key.Matches(msg, keys.SortFiles):
+			b.sortMode = (b.sortMode + 1) % len(sortNames)
+			b = b.refresh()
+		case key.Matches(msg, keys.Filter):
+			return b, b.filter.Focus()
+		}
+
+		b = b.clampOffset()
+		return b.probeVisible()
+	}
+
+	return b, nil
+}
+
+// updateFilter handles keys while the filter field has focus
+func (b fileBrowser) updateFilter(msg tea.KeyMsg) (fileBrowser, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		b.filter.Reset()
+		b.filter.Blur()
+		b = b.refresh()
+		return b.probeVisible()
+	case "enter":
+		b.filter.Blur()
+		return b, nil
+	case "up":
+		b.cursor = max(0, b.cursor-1)
+		b = b.clampOffset()
+		return b.probeVisible()
+	case "down":
+		b.cursor = min(max(0, len(b.visible)-1), b.cursor+1)
+		b = b.clampOffset()
+		return b.probeVisible()
+	}
+
+	var cmd tea.Cmd
+	b.filter, cmd = b.filter.Update(msg)
+	b.cursor = 0
+	b = b.refresh()
+
+	var probeCmd tea.Cmd
+	b, probeCmd = b.probeVisible()
+	return b, tea.Batch(cmd, probeCmd)
+}
+
+// formatSize renders a byte count in human-readable units
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// formatDuration renders seconds as M:SS or H:MM:SS
+func formatDuration(seconds float64) string {
+	total := int(seconds + 0.5)
+	h, m, s := total/3600, total%3600/60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// showPreview reports whether the window is wide enough for the preview pane
+func (b fileBrowser) showPreview() bool {
+	return b.Width >= 125 // listing is ~81 columns, the pane ~42
+}
+
+func (b fileBrowser) View() string {
+	listing := b.listingView()
+	if !b.showPreview() {
+		return listing
+	}
+
+	entry, ok := b.Highlighted()
+	if !ok {
+		return listing
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, listing, "  ", b.previewView(entry))
+}
+
+// listingView renders the directory listing and filter line
+func (b fileBrowser) listingView() string {
+	var rows []string
+
+	hidden := tr("off")
+	if b.showHidden {
+		hidden = tr("on")
+	}
+	rows = append(rows, subtitleStyle.Render(tr("%s  (sort: %s • hidden: %s)", b.Dir, tr(sortNames[b.sortMode]), hidden)))
+
+	switch {
+	case b.err != nil:
+		rows = append(rows, errorStyle.Render(b.err.Error()))
+	case len(b.visible) == 0:
+		rows = append(rows, subtitleStyle.Render(tr("No folders or media files here")))
+	}
+
+	end := min(b.offset+b.Height, len(b.visible))
+	for i := b.offset; i < end; i++ {
+		entry := b.visible[i]
+
+		name, size, duration := entry.Name+"/", "", ""
+		if !entry.IsDir {
+			name = entry.Name
+			size = formatSize(entry.Size)
+			if d, ok := b.durations[entry.Path]; ok && d >= 0 {
+				duration = formatDuration(d)
+			}
+		}
+
+		row := fmt.Sprintf("%s %10s %9s  %s",
+			padRight(truncate(name, 40), 40), size, duration, entry.ModTime.Format("2006-01-02 15:04"))
+		if i == b.cursor {
+			rows = append(rows, successStyle.Render("> "+row))
+		} else {
+			rows = append(rows, "  "+row)
+		}
+	}
+
+	// Pad so the layout doesn't jump between directories
+	for len(rows) < b.Height+1 {
+		rows = append(rows, "")
+	}
+
+	if b.filter.Focused() || b.filter.Value() != "" {
+		rows = append(rows, b.filter.View())
+	} else {
+		rows = append(rows, subtitleStyle.Render(tr("f filter • s sort • . hidden files")))
+	}
+
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	previewWidth    = 36
+	waveformColumns = 32
+	waveformRate    = 4000 // samples per second decoded for the waveform
+	previewDelay    = 250 * time.Millisecond
+)
+
+// filePreview is the information shown in the preview sidebar
+type filePreview struct {
+	Info     *MediaInfo
+	Waveform string
+	Err      error
+}
+
+// previewRequestMsg fires after the cursor has rested on a file for a moment
+type previewRequestMsg struct {
+	path string
+}
+
+// previewMsg carries a finished preview
+type previewMsg struct {
+	path    string
+	preview filePreview
+}
+
+// schedulePreview waits briefly before probing so scrolling quickly through
+// a folder doesn't start an ffprobe for every file passed
+func schedulePreview(path string) tea.Cmd {
+	return tea.Tick(previewDelay, func(time.Time) tea.Msg {
+		return previewRequestMsg{path: path}
+	})
+}
+
+// previewCmd probes a file and, when it has audio, draws its waveform
+func previewCmd(ffmpegPath, ffprobePath, path string) tea.Cmd {
+	return func() tea.Msg {
+		info, err := probeMedia(ffprobePath, path)
+		if err != nil {
+			return previewMsg{path: path, preview: filePreview{Err: err}}
+		}
+
+		preview := filePreview{Info: info}
+		if info.HasAudio() && ffmpegPath != "" && info.Duration > 0 {
+			if peaks, err := audioPeaks(ffmpegPath, path, info.Duration, waveformColumns); err == nil {
+				preview.Waveform = renderWaveform(peaks)
+			}
+		}
+		return previewMsg{path: path, preview: preview}
+	}
+}
+
+// audioPeaks decodes the audio at a low sample rate and returns the peak
+// level (0-1) of each of the given number of equal time slices
+func audioPeaks(ffmpegPath, path string, duration float64, columns int) ([]float64, error) {
+	cmd := exec.Command(ffmpegPath,
+		"-v", "error",
+		"-i", path,
+		"-vn",
+		"-ac", "1",
+		"-ar", fmt.Sprint(waveformRate),
+		"-f", "s16le",
+		"-",
+	)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	defer cmd.Wait()
+
+	samplesPerColumn := max(1, int(duration*waveformRate)/columns)
+	peaks := make([]float64, columns)
+
+	reader := bufio.NewReader(stdout)
+	var sample int16
+	for i := 0; ; i++ {
+		if err := binary.Read(reader, binary.LittleEndian, &sample); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, err
+		}
+		column := min(columns-1, i/samplesPerColumn)
+		level := math.Abs(float64(sample)) / math.MaxInt16
+		if level > peaks[column] {
+			peaks[column] = level
+		}
+	}
+
+	return peaks, nil
+}
+
+// renderWaveform draws peak levels as a row of block characters
+func renderWaveform(peaks []float64) string {
+	ramp := []rune(waveformRamp)
+	var b strings.Builder
+	for _, peak := range peaks {
+		index := int(math.Round(peak * float64(len(ramp)-1)))
+		b.WriteRune(ramp[max(0, min(len(ramp)-1, index))])
+	}
+	return b.String()
+}
+
+// formatBitRate renders bits per second in kb/s or Mb/s
+func formatBitRate(bps int64) string {
+	if bps >= 1000000 {
+		return fmt.Sprintf("%.1f Mb/s", float64(bps)/1000000)
+	}
+	return fmt.Sprintf("%d kb/s", bps/1000)
+}
+
+// previewView renders the preview sidebar for an entry
+func (b fileBrowser) previewView(entry browserEntry) string {
+	lines := []string{truncate(entry.Name, previewWidth-4), ""}
+
+	preview, ok := b.previews[entry.Path]
+	switch {
+	case entry.IsDir:
+		lines = append(lines, subtitleStyle.Render(tr("Folder")))
+	case b.ffprobePath == "":
+		lines = append(lines, subtitleStyle.Render(tr("Install ffprobe for details")))
+	case !ok:
+		lines = append(lines, subtitleStyle.Render(tr("Reading file...")))
+	case preview.Err != nil:
+		lines = append(lines, errorStyle.Render(tr("Not a readable media file")))
+	default:
+		info := preview.Info
+		lines = append(lines, tr("Duration: %s", formatDuration(info.Duration)))
+		lines = append(lines, tr("Format:   %s", truncate(info.FormatName, previewWidth-14)))
+		if info.BitRate > 0 {
+			lines = append(lines, tr("Bitrate:  %s", formatBitRate(info.BitRate)))
+		}
+		if len(info.AudioCodecs) > 0 {
+			lines = append(lines, tr("Audio:    %s", strings.Join(info.AudioCodecs, ", ")))
+			lines = append(lines, tr("          %d Hz, %d ch", info.SampleRate, info.Channels))
+		} else {
+			lines = append(lines, errorStyle.Render(tr("No audio stream")))
+		}
+		if len(info.VideoCodecs) > 0 {
+			lines = append(lines, tr("Video:    %s %dx%d", strings.Join(info.VideoCodecs, ", "), info.Width, info.Height))
+		}
+		if preview.Waveform != "" {
+			lines = append(lines, "", successStyle.Render(preview.Waveform))
+		}
+	}
+
+	return helpStyle.Width(previewWidth).Render(strings.Join(lines, "\n"))
+}
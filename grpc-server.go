@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The messages below follow proto/stt.proto. They are encoded by hand with
+// protowire so the build doesn't need protoc.
+
+// wireMessage is a message the gRPC codec can encode
+type wireMessage interface {
+	marshal() []byte
+	unmarshal(data []byte) error
+}
+
+// wireCodec encodes wireMessages in the protobuf wire format
+type wireCodec struct{}
+
+func (wireCodec) Name() string { return "proto" }
+
+func (wireCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(wireMessage)
+	if !ok {
+		return nil, fmt.Errorf("cannot encode %T", v)
+	}
+	return msg.marshal(), nil
+}
+
+func (wireCodec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(wireMessage)
+	if !ok {
+		return fmt.Errorf("cannot decode %T", v)
+	}
+	return msg.unmarshal(data)
+}
+
+// transcribeRequest is stt.v1.TranscribeRequest
+type transcribeRequest struct {
+	Audio     []byte
+	Filename  string
+	Input     string
+	Model     string
+	Language  string
+	Backend   string
+	Translate bool
+	Priority  string
+}
+
+// transcribeEvent is stt.v1.TranscribeEvent; exactly one field is set
+type transcribeEvent struct {
+	Stage   string
+	Segment *Segment
+	Result  *Transcript
+}
+
+func appendStringField(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendDoubleField(b []byte, num protowire.Number, f float64) []byte {
+	if f == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(f))
+}
+
+func appendMessageField(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func marshalSegment(seg Segment) []byte {
+	var b []byte
+	b = appendDoubleField(b, 1, seg.Start)
+	b = appendDoubleField(b, 2, seg.End)
+	b = appendStringField(b, 3, seg.Text)
+	return b
+}
+
+func marshalTranscript(t *Transcript) []byte {
+	var b []byte
+	b = appendStringField(b, 1, t.Text)
+	b = appendStringField(b, 2, t.Language)
+	b = appendDoubleField(b, 3, t.Duration)
+	for _, seg := range t.Segments {
+		b = appendMessageField(b, 4, marshalSegment(seg))
+	}
+	return b
+}
+
+func (e *transcribeEvent) marshal() []byte {
+	switch {
+	case e.Segment != nil:
+		return appendMessageField(nil, 2, marshalSegment(*e.Segment))
+	case e.Result != nil:
+		return appendMessageField(nil, 3, marshalTranscript(e.Result))
+	default:
+		b := protowire.AppendTag(nil, 1, protowire.BytesType)
+		return protowire.AppendString(b, e.Stage)
+	}
+}
+
+// The server only decodes requests and encodes events
+
+func (e *transcribeEvent) unmarshal(data []byte) error {
+	return fmt.Errorf("decoding events is not supported")
+}
+
+func (r *transcribeRequest) marshal() []byte {
+	return nil
+}
+
+func (r *transcribeRequest) unmarshal(data []byte) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			var v []byte
+			v, n = protowire.ConsumeBytes(data)
+			r.Audio = append([]byte(nil), v...)
+		case num >= 2 && num <= 6 && typ == protowire.BytesType:
+			var v string
+			v, n = protowire.ConsumeString(data)
+			switch num {
+			case 2:
+				r.Filename = v
+			case 3:
+				r.Input = v
+			case 4:
+				r.Model = v
+			case 5:
+				r.Language = v
+			case 6:
+				r.Backend = v
+			}
+		case num == 8 && typ == protowire.BytesType:
+			r.Priority, n = protowire.ConsumeString(data)
+		case num == 7 && typ == protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(data)
+			r.Translate = v != 0
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// grpcTranscriber implements stt.v1.Transcriber
+type grpcTranscriber struct {
+	server *transcriptionServer
+}
+
+// transcriberServiceDesc registers the Transcriber service, as protoc's
+// generated code would
+var transcriberServiceDesc = grpc.ServiceDesc{
+	ServiceName: "stt.v1.Transcriber",
+	HandlerType: (*any)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Transcribe",
+			ServerStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				req := new(transcribeRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(*grpcTranscriber).Transcribe(req, stream)
+			},
+		},
+	},
+	Metadata: "proto/stt.proto",
+}
+
+// newGRPCServer creates a gRPC server exposing the Transcriber service
+func newGRPCServer(s *transcriptionServer) *grpc.Server {
+	// Requests carry the whole upload plus a few small fields
+	maxSize := maxGRPCMessageSize
+	if s.maxUpload > 0 && s.maxUpload < maxGRPCMessageSize {
+		maxSize = int(s.maxUpload) + 64<<10
+	}
+	options := []grpc.ServerOption{
+		grpc.ForceServerCodec(wireCodec{}),
+		grpc.MaxRecvMsgSize(maxSize),
+	}
+	if s.auth != nil {
+		options = append(options, grpc.StreamInterceptor(s.auth.streamInterceptor))
+	}
+	server := grpc.NewServer(options...)
+	server.RegisterService(&transcriberServiceDesc, &grpcTranscriber{server: s})
+	return server
+}
+
+// maxGRPCMessageSize bounds requests, which carry the whole audio file
+const maxGRPCMessageSize = 1 << 30
+
+// Transcribe runs one job, streaming stages and segments to the client
+func (g *grpcTranscriber) Transcribe(req *transcribeRequest, stream grpc.ServerStream) error {
+	cfg, err := g.server.requestConfig(req.Model, req.Language, req.Backend, req.Translate)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	priority, err := parsePriority(req.Priority)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	job := &jobRecord{Priority: priority, Config: cfg}
+	input := req.Input
+	if len(req.Audio) > 0 {
+		path, cleanup, err := saveUpload(bytes.NewReader(req.Audio), req.Filename)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		defer cleanup()
+		input, job.Filename = path, req.Filename
+	} else if err := g.server.checkInput(input); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	} else {
+		job.Input = input
+	}
+
+	// Sending stops at the first error, e.g. when the client goes away
+	var sendErr error
+	send := func(event *transcribeEvent) {
+		if sendErr == nil {
+			sendErr = stream.SendMsg(event)
+		}
+	}
+	transcript, err := g.server.transcribe(stream.Context(), job, input, PipelineHooks{
+		Progress: func(stage string) { send(&transcribeEvent{Stage: stage}) },
+		Segment:  func(seg Segment) { send(&transcribeEvent{Segment: &seg}) },
+	})
+	switch {
+	case errors.Is(err, errDraining):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, errTooLarge):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case err != nil:
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	send(&transcribeEvent{Result: transcript})
+	return sendErr
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// uiLanguages are the choices for the interface language; auto follows the
+// locale
+var uiLanguages = []string{"auto", "en", "es"}
+
+// uiLanguage is the language the interface is shown in, set by setUILanguage
+var uiLanguage = "en"
+
+// catalogs translate the interface's English strings by language. Strings
+// missing from a catalog are shown in English.
+var catalogs = map[string]map[string]string{
+	"es": spanishMessages,
+}
+
+// tr translates an interface string. With arguments, the translation is
+// used as a format for them.
+func tr(message string, args ...any) string {
+	if translated, ok := catalogs[uiLanguage][message]; ok {
+		message = translated
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// localeLanguage returns the language of the user's locale, e.g. "es" for
+// LANG=es_MX.UTF-8. LC_ALL and LC_MESSAGES take precedence over LANG.
+func localeLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			language, _, _ := strings.Cut(value, "_")
+			language, _, _ = strings.Cut(language, ".")
+			return strings.ToLower(language)
+		}
+	}
+	return "en"
+}
+
+// setUILanguage switches the interface to the configured language, or the
+// locale's for "auto", falling back to English
+func setUILanguage(setting string) {
+	language := setting
+	if language == "" || language == "auto" {
+		language = localeLanguage()
+	}
+	if _, ok := catalogs[language]; !ok {
+		language = "en"
+	}
+	uiLanguage = language
+	localizeKeys()
+}
+
+// localize translates the text fields created before the language changed
+func (m model) localize() model {
+	m.pathInput.Prompt = tr("Path: ")
+	m.pathInput.Placeholder = tr("drop a file here or press Tab to type a path")
+	m.browser.filter.Prompt = tr("Filter: ")
+	m.browser.filter.Placeholder = tr("type to fuzzy-match file names")
+	return m
+}
+
+// keyHelpEnglish holds the English help of every binding in keys, so the
+// help can be translated again after switching languages
+var keyHelpEnglish []string
+
+// localizeKeys translates the help text of every key binding
+func localizeKeys() {
+	bindings := reflect.ValueOf(&keys).Elem()
+	if keyHelpEnglish == nil {
+		for i := 0; i < bindings.NumField(); i++ {
+			binding := bindings.Field(i).Addr().Interface().(*key.Binding)
+			keyHelpEnglish = append(keyHelpEnglish, binding.Help().Desc)
+		}
+	}
+	for i := 0; i < bindings.NumField(); i++ {
+		binding := bindings.Field(i).Addr().Interface().(*key.Binding)
+		binding.SetHelp(binding.Help().Key, tr(keyHelpEnglish[i]))
+	}
+}
+
+// spanishMessages is the Spanish interface
+var spanishMessages = map[string]string{
+	// Screens
+	"Select a video or audio file to transcribe (press '?' for help):": "Elige un archivo de vídeo o audio para transcribir (pulsa '?' para ver la ayuda):",
+	"Processing audio...": "Procesando el audio...",
+	"File: %s":            "Archivo: %s",
+	"Extracting audio and transcribing... This may take a few minutes...": "Extrayendo el audio y transcribiendo... Puede tardar unos minutos...",
+	"Error occurred:":                               "Se produjo un error:",
+	"Transcription completed":                       "Transcripción terminada",
+	"Transcription stopped:":                        "Transcripción detenida:",
+	"Stopping...":                                   "Deteniendo...",
+	"Partial transcript: stopped at %s":             "Transcripción parcial: detenida en %s",
+	"No speech detected in the audio file.":         "No se detectó voz en el archivo de audio.",
+	"%s available: run stt-cli self-update":         "%s disponible: ejecuta stt-cli self-update",
+	"Settings":                                      "Ajustes",
+	"Presets":                                       "Perfiles",
+	"Save transcript as":                            "Guardar la transcripción como",
+	"Who is speaking? Type a name for each speaker": "¿Quién habla? Escribe un nombre para cada persona",
+	"Keyboard Shortcuts":                            "Atajos de teclado",
+	"Press ? or Esc to close":                       "Pulsa ? o Esc para cerrar",
+	"Use ↑/↓ or j/k to scroll • Line %d-%d of %d • [/] segments • Space to play • Press 's' to save, 'c' to copy • Press 'n' for another file • Press 'q' to exit": "↑/↓ o j/k para desplazarte • Línea %d-%d de %d • [/] segmentos • Espacio para reproducir • 's' para guardar, 'c' para copiar • 'n' para otro archivo • 'q' para salir",
+	"[/] segments • Space to play • Press 's' to save, 'c' to copy • Press 'n' for another file • Press 'q' to exit":                                               "[/] segmentos • Espacio para reproducir • 's' para guardar, 'c' para copiar • 'n' para otro archivo • 'q' para salir",
+	"↑/↓ to move • ←/→ or Enter to change • Esc to go back • ? for help":                                                                                           "↑/↓ para moverte • ←/→ o Intro para cambiar • Esc para volver • ? para la ayuda",
+	"↑/↓ to move • Space to select • Enter to save • Esc to go back":                                                                                               "↑/↓ para moverte • Espacio para elegir • Intro para guardar • Esc para volver",
+	"↑/↓ to move • Enter to apply • Esc to go back":                                                                                                                "↑/↓ para moverte • Intro para aplicar • Esc para volver",
+	"↑/↓ to move • Ctrl+P to hear a sample • Enter to apply • Esc to keep the labels":                                                                              "↑/↓ para moverte • Ctrl+P para oír una muestra • Intro para aplicar • Esc para dejar las etiquetas",
+	"The template %s replaces these formats":                                                                                                                       "La plantilla %s sustituye a estos formatos",
+	"%d segments were finished • Press 'p' to keep them as a partial transcript, Enter for another file":                                                           "%d segmentos terminados • 'p' para conservarlos como transcripción parcial, Intro para otro archivo",
+	"%d segments transcribed • Press 'x' to stop and keep them":                                                                                                    "%d segmentos transcritos • 'x' para detener y conservarlos",
+
+	// Status messages
+	"Saved %s":                                "Guardado: %s",
+	"Copied transcript to clipboard":          "Transcripción copiada al portapapeles",
+	"Copied segment at %s":                    "Segmento de %s copiado",
+	"No segments to clip":                     "No hay segmentos que recortar",
+	"Clips can only be cut from local files":  "Solo se pueden recortar archivos locales",
+	"Saved clip to %s":                        "Fragmento guardado en %s",
+	"Playback is not available for this file": "No se puede reproducir este archivo",
+	"Select at least one format":              "Elige al menos un formato",
+	"Could not save settings: %s":             "No se pudieron guardar los ajustes: %s",
+	"Applied preset %s":                       "Perfil %s aplicado",
+	"Renamed %d of %d speakers":               "%d de %d personas renombradas",
+	"Cannot open %s":                          "No se puede abrir %s",
+
+	// Settings
+	"Model size":             "Tamaño del modelo",
+	"Language":               "Idioma",
+	"Output format":          "Formato de salida",
+	"Backend":                "Motor",
+	"Translate to English":   "Traducir al inglés",
+	"Tag mixed languages":    "Marcar otros idiomas",
+	"Identify speakers":      "Identificar personas",
+	"Tone and keywords":      "Tono y palabras clave",
+	"Voice band filter":      "Filtro de voz",
+	"Noise reduction":        "Reducción de ruido",
+	"Loudness normalization": "Normalizar volumen",
+	"Mark uncertain words":   "Marcar palabras dudosas",
+	"Numbers as digits":      "Números en cifras",
+	"Format dates":           "Formatear fechas",
+	"Currency symbols":       "Símbolos de moneda",
+	"Capitalize sentences":   "Mayúsculas al empezar",
+	"Interface language":     "Idioma de la interfaz",
+	"Timestamps":             "Marcas de tiempo",
+	"Frame rate":             "Fotogramas/segundo",
+
+	// The decimal separator of times shown in seconds
+	".": ",",
+
+	// File browser and preview
+	"Path: ":   "Ruta: ",
+	"Filter: ": "Filtro: ",
+	"drop a file here or press Tab to type a path": "suelta aquí un archivo o pulsa Tab para escribir una ruta",
+	"type to fuzzy-match file names":               "escribe para buscar nombres de archivo",
+	"%s  (sort: %s • hidden: %s)":                  "%s  (orden: %s • ocultos: %s)",
+	"name":                                         "nombre",
+	"date":                                         "fecha",
+	"size":                                         "tamaño",
+	"on":                                           "sí",
+	"off":                                          "no",
+	"No folders or media files here":               "Aquí no hay carpetas ni archivos multimedia",
+	"f filter • s sort • . hidden files":           "f filtrar • s ordenar • . archivos ocultos",
+	"Folder":                                       "Carpeta",
+	"Install ffprobe for details":                  "Instala ffprobe para ver detalles",
+	"Reading file...":                              "Leyendo el archivo...",
+	"Not a readable media file":                    "No es un archivo multimedia legible",
+	"No audio stream":                              "Sin pista de audio",
+	"Duration: %s":                                 "Duración: %s",
+	"Format:   %s":                                 "Formato:  %s",
+	"Video:    %s %dx%d":                           "Vídeo:    %s %dx%d",
+	"Bitrate:  %s":                                 "Bits/s:   %s",
+	"Audio:    %s":                                 "Audio:    %s",
+	"          %d Hz, %d ch":                       "          %d Hz, %d can.",
+
+	// Results
+	"Save":                   "Guardar",
+	"Copy":                   "Copiar",
+	"New file":               "Otro archivo",
+	"Tone: %s (%+.2f)  ":     "Tono: %s (%+.2f)  ",
+	"  selected: %s (%+.2f)": "  seleccionado: %s (%+.2f)",
+	"Keywords: ":             "Palabras clave: ",
+	"Topics: ":               "Temas: ",
+	"positive":               "positivo",
+	"negative":               "negativo",
+	"neutral":                "neutro",
+
+	// Key help
+	"move up":                      "subir",
+	"move down":                    "bajar",
+	"go to beginning":              "ir al principio",
+	"go to end":                    "ir al final",
+	"select file or open folder":   "elegir archivo o abrir carpeta",
+	"parent folder":                "carpeta superior",
+	"filter file names":            "filtrar nombres de archivo",
+	"sort by name/date/size":       "ordenar por nombre/fecha/tamaño",
+	"show hidden files":            "mostrar archivos ocultos",
+	"type or drop a path":          "escribir o soltar una ruta",
+	"leave path field":             "salir del campo de ruta",
+	"keep filter":                  "mantener el filtro",
+	"clear filter":                 "borrar el filtro",
+	"save transcript as...":        "guardar la transcripción como...",
+	"copy transcript":              "copiar la transcripción",
+	"transcribe another file":      "transcribir otro archivo",
+	"stop and keep what's done":    "detener y conservar lo hecho",
+	"keep the partial transcript":  "conservar la transcripción parcial",
+	"play/pause audio":             "reproducir/pausar el audio",
+	"play from selected segment":   "reproducir desde el segmento elegido",
+	"next segment":                 "segmento siguiente",
+	"previous segment":             "segmento anterior",
+	"copy segment with timestamp":  "copiar el segmento con su hora",
+	"save segment audio clip":      "guardar el audio del segmento",
+	"rename speakers":              "renombrar personas",
+	"previous speaker":             "persona anterior",
+	"next speaker":                 "persona siguiente",
+	"play a sample of the speaker": "oír una muestra de la persona",
+	"apply names":                  "aplicar los nombres",
+	"keep speaker labels":          "dejar las etiquetas",
+	"select format":                "elegir formato",
+	"save selected formats":        "guardar los formatos elegidos",
+	"next value / toggle":          "valor siguiente / activar",
+	"previous value":               "valor anterior",
+	"go back":                      "volver",
+	"settings":                     "ajustes",
+	"presets":                      "perfiles",
+	"apply preset":                 "aplicar perfil",
+	"toggle help":                  "mostrar u ocultar la ayuda",
+	"quit":                         "salir",
+
+	// Plain mode
+	"Enter the path of a video or audio file (empty line to quit): ": "Escribe la ruta de un archivo de vídeo o audio (línea vacía para salir): ",
+	"Processing %s":                 "Procesando %s",
+	"Status: %s":                    "Estado: %s",
+	"%s said:":                      "%s dijo:",
+	"Name for %s (empty to keep): ": "Nombre para %s (vacío para mantenerlo): ",
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// supportedExtensions lists the file types offered in the file picker.
+// ffmpeg handles far more than this, so the list only drives browsing;
+// the actual check is done by probing the file with ffprobe.
+var supportedExtensions = []string{
+	// Video containers
+	".mp4", ".avi", ".mov", ".mkv", ".webm", ".ts", ".3gp",
+	// Audio files
+	".mp3", ".wav", ".m4a", ".flac", ".ogg", ".opus", ".aac", ".wma", ".amr",
+}
+
+// MediaInfo holds the parts of ffprobe's output we care about
+type MediaInfo struct {
+	FormatName  string
+	Duration    float64
+	BitRate     int64
+	AudioCodecs []string
+	VideoCodecs []string
+	SampleRate  int
+	Channels    int
+	Width       int
+	Height      int
+}
+
+// HasAudio reports whether the file contains at least one audio stream
+func (mi *MediaInfo) HasAudio() bool {
+	return len(mi.AudioCodecs) > 0
+}
+
+// ffprobeOutput mirrors the JSON printed by ffprobe -of json
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType  string `json:"codec_type"`
+		CodecName  string `json:"codec_name"`
+		SampleRate string `json:"sample_rate"`
+		Channels   int    `json:"channels"`
+		Width      int    `json:"width"`
+		Height     int    `json:"height"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+// isSupportedExtension reports whether the path has one of the known extensions
+func isSupportedExtension(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, allowed := range supportedExtensions {
+		if ext == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// findFFprobe locates ffprobe, preferring PATH and falling back to the
+// directory ffmpeg was found in
+func findFFprobe(ffmpegPath string) string {
+	return findFFmpegTool("ffprobe", ffmpegPath)
+}
+
+// findFFmpegTool locates one of the tools shipped with FFmpeg (ffprobe,
+// ffplay), preferring PATH and falling back to the directory ffmpeg was
+// found in
+func findFFmpegTool(tool, ffmpegPath string) string {
+	if path, err := exec.LookPath(tool); err == nil {
+		return path
+	}
+
+	name := tool
+	if strings.HasSuffix(strings.ToLower(ffmpegPath), ".exe") {
+		name += ".exe"
+	}
+	candidate := filepath.Join(filepath.Dir(ffmpegPath), name)
+	if _, err := os.Stat(candidate); err == nil {
+		return candidate
+	}
+	return ""
+}
+
+// probeMedia runs ffprobe on a file and returns its container and stream info
+func probeMedia(ffprobePath, path string) (*MediaInfo, error) {
+	cmd := exec.Command(ffprobePath,
+		"-v", "error",
+		"-show_entries", "stream=codec_type,codec_name,sample_rate,channels,width,height:format=format_name,duration,bit_rate",
+		"-of", "json",
+		path,
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("ffprobe error: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("ffprobe error: %w", err)
+	}
+
+	var probe ffprobeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	info := &MediaInfo{FormatName: probe.Format.FormatName}
+	if probe.Format.Duration != "" {
+		info.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	}
+	if probe.Format.BitRate != "" {
+		info.BitRate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+	}
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "audio":
+			// Describe the first audio stream, which is the one we transcribe
+			if len(info.AudioCodecs) == 0 {
+				info.SampleRate, _ = strconv.Atoi(stream.SampleRate)
+				info.Channels = stream.Channels
+			}
+			info.AudioCodecs = append(info.AudioCodecs, stream.CodecName)
+		case "video":
+			if len(info.VideoCodecs) == 0 {
+				info.Width, info.Height = stream.Width, stream.Height
+			}
+			info.VideoCodecs = append(info.VideoCodecs, stream.CodecName)
+		}
+	}
+
+	return info, nil
+}
+
+// validateInput checks that the input can be transcribed. When ffprobe is
+// available the file is probed for an audio stream, so files with unusual
+// or missing extensions still work; otherwise we fall back to the extension.
+func (p *AudioProcessor) validateInput() error {
+	if _, err := os.Stat(p.InputPath); err != nil {
+		return fmt.Errorf("cannot read input file: %w", err)
+	}
+
+	if p.FFprobePath == "" {
+		if !isSupportedExtension(p.InputPath) {
+			return fmt.Errorf("unsupported file type %q (install ffprobe to detect formats by content)", filepath.Ext(p.InputPath))
+		}
+		return nil
+	}
+
+	info, err := probeMedia(p.FFprobePath, p.InputPath)
+	if err != nil {
+		return fmt.Errorf("%s is not a recognized media file: %w", filepath.Base(p.InputPath), err)
+	}
+	if !info.HasAudio() {
+		return fmt.Errorf("%s has no audio stream", filepath.Base(p.InputPath))
+	}
+	p.Media = info
+
+	return nil
+}
@@ -0,0 +1,218 @@
+//go:build integration
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The integration tests run the audio fixtures in testdata/integration
+// through the whole pipeline, ffmpeg and Python included, and compare the
+// transcripts with golden ones recorded earlier:
+//
+//	go test -tags integration -run Integration .
+//	go test -tags integration -run Integration . -args -update
+//	go test -tags integration -run Integration . -args -backend whisper -model tiny
+//
+// The fake backend needs neither a model nor the network, so its goldens
+// pin down extraction and the pipeline around the model. Goldens for the
+// real backends are recorded per model with -update.
+//
+// A fixture with a .txt file next to it is speech, and the .txt is what is
+// said. Real backends are also checked against that reference, so their
+// goldens can't record a wrong transcript. The spoken fixtures are made
+// from the references with a text-to-speech voice:
+//
+//	go test -tags integration -run Integration . -args -speak
+var (
+	updateGolden    = flag.Bool("update", false, "rewrite the golden transcripts with this run's")
+	goldenBackend   = flag.String("backend", "fake", "backend to transcribe the fixtures with")
+	goldenModel     = flag.String("model", "tiny", "model to transcribe the fixtures with")
+	fixturesDir     = flag.String("fixtures", filepath.Join("testdata", "integration"), "directory of audio fixtures and golden transcripts")
+	timeTolerance   = flag.Float64("tolerance", 0.5, "seconds segment times may differ from the golden ones")
+	errorTolerance  = flag.Float64("max-wer", 0.1, "word error rate allowed against the golden text")
+	speechTolerance = flag.Float64("max-reference-wer", 0.25, "word error rate allowed against what a spoken fixture says")
+	speakFixtures   = flag.Bool("speak", false, "record spoken fixtures missing for .txt references with espeak-ng or say")
+)
+
+// referencePath returns the text said in a spoken fixture
+func referencePath(fixture string) string {
+	return strings.TrimSuffix(fixture, filepath.Ext(fixture)) + ".txt"
+}
+
+// speak records each reference without a fixture as a 16 kHz mono WAV
+// file, read by espeak-ng or, on macOS, say
+func speak(t *testing.T, ffmpegPath, dir string) {
+	references, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, reference := range references {
+		fixture := strings.TrimSuffix(reference, ".txt") + ".wav"
+		if _, err := os.Stat(fixture); err == nil {
+			continue
+		}
+		var voiced string
+		var cmd *exec.Cmd
+		if path, err := exec.LookPath("espeak-ng"); err == nil {
+			voiced = filepath.Join(t.TempDir(), "voice.wav")
+			cmd = exec.Command(path, "-v", "en-us", "-s", "150", "-f", reference, "-w", voiced)
+		} else if path, err := exec.LookPath("say"); err == nil {
+			voiced = filepath.Join(t.TempDir(), "voice.aiff")
+			cmd = exec.Command(path, "-f", reference, "-o", voiced)
+		} else {
+			t.Fatal("-speak needs espeak-ng or say")
+		}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("reading %s aloud: %v\n%s", reference, err, out)
+		}
+		convert := exec.Command(ffmpegPath, "-y", "-i", voiced, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", fixture)
+		if out, err := convert.CombinedOutput(); err != nil {
+			t.Fatalf("converting %s: %v\n%s", fixture, err, out)
+		}
+		t.Logf("recorded %s; commit it with its goldens", fixture)
+	}
+}
+
+// goldenPath returns where a fixture's golden transcript for the backend
+// and model is kept. The fake backend's doesn't depend on the model.
+func goldenPath(fixture string) string {
+	name := *goldenBackend
+	if name != fakeBackend.Name {
+		name += "-" + *goldenModel
+	}
+	return strings.TrimSuffix(fixture, filepath.Ext(fixture)) + "." + name + ".golden.json"
+}
+
+func TestIntegrationPipeline(t *testing.T) {
+	ffmpegPath, err := findFFmpeg()
+	if err != nil {
+		t.Skip("ffmpeg is not installed")
+	}
+	if _, err := exec.LookPath("python"); err != nil {
+		t.Skip("python is not in PATH")
+	}
+	if *speakFixtures {
+		speak(t, ffmpegPath, *fixturesDir)
+	}
+
+	var fixtures []string
+	entries, err := os.ReadDir(*fixturesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if path := filepath.Join(*fixturesDir, entry.Name()); isSupportedExtension(path) {
+			fixtures = append(fixtures, path)
+		}
+	}
+	if len(fixtures) == 0 {
+		t.Fatalf("no audio fixtures in %s", *fixturesDir)
+	}
+
+	cfg := defaultConfig()
+	cfg.Backend = *goldenBackend
+	cfg.Model = *goldenModel
+	if _, err := pipelineBackend(cfg.Backend); err != nil {
+		t.Fatal(err)
+	}
+
+	// Chimes only show that a real backend runs, not that it hears words
+	spoken := 0
+	for _, fixture := range fixtures {
+		if _, err := os.Stat(referencePath(fixture)); err == nil {
+			spoken++
+		}
+	}
+	if spoken == 0 && cfg.Backend != fakeBackend.Name {
+		t.Fatalf("no spoken fixtures in %s to check %s with; record them with -speak", *fixturesDir, cfg.Backend)
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			got, _, err := runPipeline(context.Background(), fixture, cfg, PipelineHooks{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The path depends on where the tests run
+			got.Source = filepath.Base(fixture)
+
+			if reference, err := os.ReadFile(referencePath(fixture)); err == nil && cfg.Backend != fakeBackend.Name {
+				if rate := wordErrorRate(string(reference), got.Text); rate > *speechTolerance {
+					t.Errorf("heard %.0f%% of words wrong\n got: %s\nwant: %s", rate*100, got.Text, strings.TrimSpace(string(reference)))
+				}
+			}
+
+			for _, format := range outputFormats {
+				if format == "anki" {
+					continue // cuts clips with ffmpeg; written by writeAnkiDeck
+				}
+				if _, err := renderFormat(got, format, cfg); err != nil {
+					t.Errorf("rendering %s: %v", format, err)
+				}
+			}
+
+			golden := goldenPath(fixture)
+			if *updateGolden {
+				data, err := formatTranscript(got, "json", cfg.Timestamps)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, data, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			data, err := os.ReadFile(golden)
+			if os.IsNotExist(err) {
+				t.Skipf("no golden transcript %s; record one with -update", golden)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want Transcript
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatalf("parsing %s: %v", golden, err)
+			}
+			compareTranscripts(t, got, &want)
+		})
+	}
+}
+
+// compareTranscripts checks a transcript against a golden one, allowing
+// for the timing and wording differences between runs and versions of a
+// model set by -tolerance and -max-wer
+func compareTranscripts(t *testing.T, got, want *Transcript) {
+	t.Helper()
+	if got.Language != want.Language {
+		t.Errorf("language %q, want %q", got.Language, want.Language)
+	}
+	if math.Abs(got.Duration-want.Duration) > *timeTolerance {
+		t.Errorf("duration %.2fs, want %.2fs", got.Duration, want.Duration)
+	}
+	if rate := wordErrorRate(want.Text, got.Text); rate > *errorTolerance {
+		t.Errorf("text differs by %.0f%% of words\n got: %s\nwant: %s", rate*100, got.Text, want.Text)
+	}
+
+	if len(got.Segments) != len(want.Segments) {
+		t.Fatalf("%d segments, want %d", len(got.Segments), len(want.Segments))
+	}
+	for i, seg := range got.Segments {
+		golden := want.Segments[i]
+		if math.Abs(seg.Start-golden.Start) > *timeTolerance || math.Abs(seg.End-golden.End) > *timeTolerance {
+			t.Errorf("segment %d at %.2f-%.2fs, want %.2f-%.2fs", i+1, seg.Start, seg.End, golden.Start, golden.End)
+		}
+		if rate := wordErrorRate(golden.Text, seg.Text); rate > *errorTolerance {
+			t.Errorf("segment %d is %q, want %q", i+1, seg.Text, golden.Text)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// runJobs lists, inspects, retries and prunes recorded jobs
+func runJobs(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: stt-cli jobs list|show|retry|prune [flags]")
+	}
+
+	// The config file has the history's key and retention
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("jobs "+args[0], flag.ContinueOnError)
+	dbPath := fs.String("db", "", "job database (default: jobs.db next to the config file)")
+
+	switch args[0] {
+	case "list":
+		status := fs.String("status", "", "only show jobs with this status")
+		limit := fs.Int("limit", 20, "number of jobs to show")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		store, err := openJobStore(*dbPath, cfg.History)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		return listJobs(store, *status, *limit)
+
+	case "prune":
+		days := fs.Int("days", cfg.History.RetentionDays, "remove jobs finished more than this many days ago")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *days <= 0 {
+			return fmt.Errorf("pass --days or set history.retention_days in the config")
+		}
+		store, err := openJobStore(*dbPath, HistoryConfig{KeyFile: cfg.History.KeyFile})
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		removed, err := store.prune(retentionCutoff(*days))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d jobs finished more than %d days ago\n", removed, *days)
+		return nil
+
+	case "show", "retry":
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: stt-cli jobs %s [--db FILE] ID", args[0])
+		}
+		id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid job ID %q", fs.Arg(0))
+		}
+		store, err := openJobStore(*dbPath, cfg.History)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		job, err := store.get(id)
+		if err != nil {
+			return err
+		}
+		if args[0] == "show" {
+			return showJob(store, job)
+		}
+		return retryJob(store, job)
+	}
+
+	return fmt.Errorf("unknown jobs command %q (use list, show, retry or prune)", args[0])
+}
+
+// formatJobTime renders a job timestamp, or - when it is unset
+func formatJobTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// listJobs prints a table of recent jobs
+func listJobs(store *jobStore, status string, limit int) error {
+	jobs, err := store.list(status, limit)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		fmt.Println("No jobs recorded yet")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tMODE\tCREATED\tTIME\tSOURCE")
+	for _, job := range jobs {
+		elapsed := "-"
+		if !job.Started.IsZero() && !job.Finished.IsZero() {
+			elapsed = job.Finished.Sub(job.Started).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			job.ID, job.Status, job.Mode, formatJobTime(job.Created), elapsed, job.source())
+	}
+	return w.Flush()
+}
+
+// showJob prints everything recorded about a job and its transcript text
+func showJob(store *jobStore, job jobRecord) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Job\t%d\n", job.ID)
+	fmt.Fprintf(w, "Status\t%s\n", job.Status)
+	if job.Error != "" {
+		fmt.Fprintf(w, "Error\t%s (%s stage)\n", job.Error, job.Stage)
+	}
+	fmt.Fprintf(w, "Mode\t%s\n", job.Mode)
+	fmt.Fprintf(w, "Source\t%s\n", job.source())
+	fmt.Fprintf(w, "Model\t%s (%s)\n", job.Config.Model, job.Config.Backend)
+	fmt.Fprintf(w, "Language\t%s\n", job.Config.Language)
+	if job.Mode == "serve" {
+		fmt.Fprintf(w, "Priority\t%s\n", job.Priority)
+	}
+	if job.RetryOf != 0 {
+		fmt.Fprintf(w, "Retry of\t%d\n", job.RetryOf)
+	}
+	fmt.Fprintf(w, "Created\t%s\n", formatJobTime(job.Created))
+	fmt.Fprintf(w, "Started\t%s\n", formatJobTime(job.Started))
+	fmt.Fprintf(w, "Finished\t%s\n", formatJobTime(job.Finished))
+	if job.AudioSeconds > 0 {
+		fmt.Fprintf(w, "Audio\t%s\n", time.Duration(job.AudioSeconds*float64(time.Second)).Round(time.Second))
+		fmt.Fprintf(w, "Transcribing\t%s (%.2fx real time)\n",
+			job.Transcribing.Round(time.Second), job.Transcribing.Seconds()/job.AudioSeconds)
+	}
+	for _, output := range job.Outputs {
+		fmt.Fprintf(w, "Output\t%s\n", output)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Server transcripts are kept as JSON, possibly encrypted; show their text
+	for _, output := range job.Outputs {
+		if filepath.Ext(strings.TrimSuffix(output, encryptedExtension)) != ".json" {
+			continue
+		}
+		data, err := store.readTranscript(output)
+		if err != nil {
+			fmt.Printf("\n(transcript unavailable: %v)\n", err)
+			break
+		}
+		var transcript Transcript
+		if err := json.Unmarshal(data, &transcript); err == nil {
+			fmt.Printf("\n%s\n", transcript.Text)
+		}
+		break
+	}
+	return nil
+}
+
+// retryJob runs a job again with its recorded settings. Batch jobs write
+// their transcripts where the original did; server jobs keep theirs in the
+// job store.
+func retryJob(store *jobStore, job jobRecord) error {
+	if job.Input == "" {
+		return fmt.Errorf("job %d transcribed an upload, which isn't kept; submit the file again", job.ID)
+	}
+	if job.Status == jobQueued || job.Status == jobRunning {
+		return fmt.Errorf("job %d is still %s", job.ID, job.Status)
+	}
+
+	retry := &jobRecord{
+		Mode:     job.Mode,
+		Input:    job.Input,
+		Priority: job.Priority,
+		Config:   job.Config,
+		RetryOf:  job.ID,
+	}
+	if err := store.create(retry); err != nil {
+		return err
+	}
+	fmt.Printf("Retrying job %d as job %d: %s\n", job.ID, retry.ID, job.source())
+
+	store.start(retry)
+	transcript, stats, err := runPipeline(context.Background(), retry.Input, retry.Config, PipelineHooks{
+		Progress: func(stage string) { fmt.Printf("  %s\n", stage) },
+	})
+
+	var outputs []string
+	if err == nil {
+		if job.Mode == "serve" {
+			var path string
+			if path, err = store.saveTranscript(retry, transcript); path != "" {
+				outputs = append(outputs, path)
+			}
+		} else {
+			outputDir := ""
+			if len(job.Outputs) > 0 {
+				outputDir = filepath.Dir(job.Outputs[0])
+			}
+			outputs, err = writeTranscripts(transcript, transcriptPath(retry.Input, outputDir, ""), retry.Config)
+		}
+	}
+	if finishErr := store.finish(retry, stats, outputs, err); finishErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record job: %v\n", finishErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, output := range outputs {
+		fmt.Printf("  saved %s\n", output)
+	}
+	if len(outputs) == 0 {
+		fmt.Println(strings.TrimSpace(transcript.Text))
+	}
+	return nil
+}
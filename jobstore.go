@@ -0,0 +1,402 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Job statuses, from submission to the end of the run
+const (
+	jobQueued      = "queued"
+	jobRunning     = "running"
+	jobSucceeded   = "succeeded"
+	jobFailed      = "failed"
+	jobCancelled   = "cancelled"
+	jobInterrupted = "interrupted" // the process exited while the job ran
+)
+
+// jobRecord is one transcription job as kept in the job store
+type jobRecord struct {
+	ID       int64
+	Mode     string // the command that ran the job: transcribe or serve
+	Input    string // path or URL, empty for uploads
+	Filename string // name of the uploaded file
+	Priority jobPriority
+	Config   Config
+	RetryOf  int64
+
+	Status string
+	Stage  string // pipeline stage that failed
+	Error  string
+
+	Created  time.Time
+	Started  time.Time
+	Finished time.Time
+
+	AudioSeconds float64
+	Transcribing time.Duration
+	Outputs      []string // transcript files written for the job
+}
+
+// source describes what the job transcribed
+func (j jobRecord) source() string {
+	if j.Input != "" {
+		return j.Input
+	}
+	return j.Filename + " (upload)"
+}
+
+// jobStore keeps job history in an SQLite database so it survives restarts.
+// A nil store records nothing, so history never gets in the way of a run.
+type jobStore struct {
+	db  *sql.DB
+	dir string // transcripts of server jobs are kept here
+	key []byte // encrypts the kept transcripts when set
+}
+
+const jobSchema = `
+CREATE TABLE IF NOT EXISTS jobs (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	mode          TEXT NOT NULL,
+	input         TEXT NOT NULL DEFAULT '',
+	filename      TEXT NOT NULL DEFAULT '',
+	priority      TEXT NOT NULL DEFAULT 'normal',
+	config        TEXT NOT NULL,
+	retry_of      INTEGER NOT NULL DEFAULT 0,
+	status        TEXT NOT NULL,
+	stage         TEXT NOT NULL DEFAULT '',
+	error         TEXT NOT NULL DEFAULT '',
+	created_at    INTEGER NOT NULL,
+	started_at    INTEGER NOT NULL DEFAULT 0,
+	finished_at   INTEGER NOT NULL DEFAULT 0,
+	audio_seconds REAL NOT NULL DEFAULT 0,
+	transcribing  REAL NOT NULL DEFAULT 0,
+	outputs       TEXT NOT NULL DEFAULT '[]'
+);
+CREATE INDEX IF NOT EXISTS jobs_status ON jobs(status);
+`
+
+// defaultJobStorePath returns the database location next to the config file
+func defaultJobStorePath() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "jobs.db"), nil
+}
+
+// openJobStore opens or creates the database at path, or the default
+// location when path is empty, and removes the jobs older than the
+// history's retention
+func openJobStore(path string, history HistoryConfig) (*jobStore, error) {
+	if path == "" {
+		var err error
+		if path, err = defaultJobStorePath(); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create job store directory: %w", err)
+	}
+
+	// WAL and a busy timeout let the server and batch runs share the file
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job store: %w", err)
+	}
+	if _, err := db.Exec(jobSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open job store %s: %w", path, err)
+	}
+
+	store := &jobStore{db: db, dir: filepath.Join(filepath.Dir(path), "transcripts")}
+	if history.KeyFile != "" {
+		if store.key, err = loadKeyFile(history.KeyFile); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	if history.RetentionDays > 0 {
+		if _, err := store.prune(retentionCutoff(history.RetentionDays)); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to remove old jobs: %w", err)
+		}
+	}
+	return store, nil
+}
+
+// retentionCutoff returns the time before which finished jobs are removed
+func retentionCutoff(days int) time.Time {
+	return time.Now().AddDate(0, 0, -days)
+}
+
+// Close closes the database
+func (s *jobStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// unixMillis stores times as milliseconds, with 0 for unset
+func unixMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func fromUnixMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// create records a new queued job and sets its ID
+func (s *jobStore) create(job *jobRecord) error {
+	if s == nil {
+		return nil
+	}
+
+	// Presets and UI state aren't needed to rerun the job
+	cfg := job.Config
+	cfg.Presets = nil
+	cfg.LastDirectory = ""
+	cfg.History = HistoryConfig{}
+	config, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	job.Status = jobQueued
+	job.Created = time.Now()
+	result, err := s.db.Exec(
+		`INSERT INTO jobs (mode, input, filename, priority, config, retry_of, status, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.Mode, job.Input, job.Filename, job.Priority.String(), string(config), job.RetryOf, job.Status, unixMillis(job.Created))
+	if err != nil {
+		return fmt.Errorf("failed to record job: %w", err)
+	}
+	job.ID, err = result.LastInsertId()
+	return err
+}
+
+// start marks a job as running
+func (s *jobStore) start(job *jobRecord) error {
+	if s == nil {
+		return nil
+	}
+	job.Status = jobRunning
+	job.Started = time.Now()
+	_, err := s.db.Exec(`UPDATE jobs SET status = ?, started_at = ? WHERE id = ?`,
+		job.Status, unixMillis(job.Started), job.ID)
+	return err
+}
+
+// finish records the outcome of a job
+func (s *jobStore) finish(job *jobRecord, stats RunStats, outputs []string, runErr error) error {
+	if s == nil {
+		return nil
+	}
+
+	job.Status, job.Stage, job.Error = jobSucceeded, "", ""
+	if runErr != nil {
+		job.Status, job.Stage, job.Error = jobFailed, failureStage(runErr), runErr.Error()
+		if job.Stage == "cancelled" {
+			job.Status = jobCancelled
+		}
+	}
+	job.Finished = time.Now()
+	job.AudioSeconds = stats.AudioDuration
+	job.Transcribing = stats.Transcribing
+	job.Outputs = outputs
+
+	data, err := json.Marshal(outputs)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(
+		`UPDATE jobs SET status = ?, stage = ?, error = ?, finished_at = ?, audio_seconds = ?, transcribing = ?, outputs = ?
+		 WHERE id = ?`,
+		job.Status, job.Stage, job.Error, unixMillis(job.Finished), job.AudioSeconds, job.Transcribing.Seconds(), string(data), job.ID)
+	return err
+}
+
+// interruptStale marks jobs of mode left queued or running by a process
+// that exited without finishing them
+func (s *jobStore) interruptStale(mode string) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(
+		`UPDATE jobs SET status = ?, error = 'the process exited before the job finished', finished_at = ?
+		 WHERE mode = ? AND status IN (?, ?)`,
+		jobInterrupted, unixMillis(time.Now()), mode, jobQueued, jobRunning)
+	return err
+}
+
+// saveTranscript keeps a server job's transcript, which is otherwise only
+// sent to the client, and returns where it was written. With a key, the
+// transcript is encrypted.
+func (s *jobStore) saveTranscript(job *jobRecord, t *Transcript) (string, error) {
+	if s == nil || job.ID == 0 {
+		return "", nil
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	data, err := formatTranscript(t, "json", TimestampConfig{})
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.dir, strconv.FormatInt(job.ID, 10)+".json")
+	if s.key != nil {
+		if data, err = sealData(s.key, data); err != nil {
+			return "", fmt.Errorf("failed to encrypt transcript: %w", err)
+		}
+		path += encryptedExtension
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to save transcript: %w", err)
+	}
+	return path, nil
+}
+
+// readTranscript reads a transcript kept by saveTranscript, decrypting it
+// if needed
+func (s *jobStore) readTranscript(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || filepath.Ext(path) != encryptedExtension {
+		return data, err
+	}
+	if s.key == nil {
+		return nil, fmt.Errorf("%s is encrypted; set history.key_file in the config", filepath.Base(path))
+	}
+	return openData(s.key, data)
+}
+
+// prune removes the jobs that finished before cutoff, and the transcripts
+// kept for them, and returns how many were removed. Transcripts written
+// next to the input files are the user's and are left alone.
+func (s *jobStore) prune(cutoff time.Time) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+	rows, err := s.db.Query(`SELECT id, outputs FROM jobs WHERE finished_at != 0 AND finished_at < ?`, unixMillis(cutoff))
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	var kept []string
+	for rows.Next() {
+		var id int64
+		var outputs string
+		if err := rows.Scan(&id, &outputs); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		var paths []string
+		json.Unmarshal([]byte(outputs), &paths)
+		for _, path := range paths {
+			if filepath.Dir(path) == s.dir {
+				kept = append(kept, path)
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, path := range kept {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("failed to remove transcript: %w", err)
+		}
+	}
+	for _, id := range ids {
+		if _, err := s.db.Exec(`DELETE FROM jobs WHERE id = ?`, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+const jobColumns = `id, mode, input, filename, priority, config, retry_of, status, stage, error,
+	created_at, started_at, finished_at, audio_seconds, transcribing, outputs`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row rowScanner) (jobRecord, error) {
+	var job jobRecord
+	var priority, config, outputs string
+	var created, started, finished int64
+	var transcribing float64
+	err := row.Scan(&job.ID, &job.Mode, &job.Input, &job.Filename, &priority, &config, &job.RetryOf,
+		&job.Status, &job.Stage, &job.Error, &created, &started, &finished,
+		&job.AudioSeconds, &transcribing, &outputs)
+	if err != nil {
+		return job, err
+	}
+
+	job.Priority, _ = parsePriority(priority)
+	job.Config = defaultConfig()
+	if err := json.Unmarshal([]byte(config), &job.Config); err != nil {
+		return job, fmt.Errorf("job %d has an invalid config: %w", job.ID, err)
+	}
+	json.Unmarshal([]byte(outputs), &job.Outputs)
+	job.Created = fromUnixMillis(created)
+	job.Started = fromUnixMillis(started)
+	job.Finished = fromUnixMillis(finished)
+	job.Transcribing = time.Duration(transcribing * float64(time.Second))
+	return job, nil
+}
+
+// get loads one job
+func (s *jobStore) get(id int64) (jobRecord, error) {
+	job, err := scanJob(s.db.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return job, fmt.Errorf("no job with ID %d", id)
+	}
+	return job, err
+}
+
+// list returns the most recent jobs first, optionally only those with status
+func (s *jobStore) list(status string, limit int) ([]jobRecord, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs`
+	var args []any
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []jobRecord
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
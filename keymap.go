@@ -0,0 +1,318 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyMap holds every keybinding in the application
+type keyMap struct {
+	Up           key.Binding
+	Down         key.Binding
+	Top          key.Binding
+	Bottom       key.Binding
+	Open         key.Binding
+	Back         key.Binding
+	Filter       key.Binding
+	SortFiles    key.Binding
+	ToggleHidden key.Binding
+	TypePath     key.Binding
+	LeavePath    key.Binding
+	LeaveFilter  key.Binding
+	ClearFilter  key.Binding
+	Save         key.Binding
+	Copy         key.Binding
+	Play         key.Binding
+	Seek         key.Binding
+	NextSegment  key.Binding
+	PrevSegment  key.Binding
+	CopySegment  key.Binding
+	ClipSegment  key.Binding
+	Rename       key.Binding
+	PrevSpeaker  key.Binding
+	NextSpeaker  key.Binding
+	PlaySample   key.Binding
+	ApplyNames   key.Binding
+	SkipNames    key.Binding
+	ToggleFormat key.Binding
+	Export       key.Binding
+	NewFile      key.Binding
+	Stop         key.Binding
+	KeepPartial  key.Binding
+	Change       key.Binding
+	Previous     key.Binding
+	Close        key.Binding
+	Settings     key.Binding
+	Presets      key.Binding
+	ApplyPreset  key.Binding
+	Help         key.Binding
+	Quit         key.Binding
+	ForceQuit    key.Binding
+}
+
+var keys = keyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Top: key.NewBinding(
+		key.WithKeys("home"),
+		key.WithHelp("home", "go to beginning"),
+	),
+	Bottom: key.NewBinding(
+		key.WithKeys("end"),
+		key.WithHelp("end", "go to end"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("enter", "right", "l"),
+		key.WithHelp("enter/→", "select file or open folder"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("backspace", "left", "h"),
+		key.WithHelp("backspace/←", "parent folder"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "filter file names"),
+	),
+	SortFiles: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "sort by name/date/size"),
+	),
+	ToggleHidden: key.NewBinding(
+		key.WithKeys("."),
+		key.WithHelp(".", "show hidden files"),
+	),
+	TypePath: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "type or drop a path"),
+	),
+	LeavePath: key.NewBinding(
+		key.WithKeys("esc", "tab"),
+		key.WithHelp("esc", "leave path field"),
+	),
+	LeaveFilter: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "keep filter"),
+	),
+	ClearFilter: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "clear filter"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "save transcript as..."),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "copy transcript"),
+	),
+	NewFile: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "transcribe another file"),
+	),
+	Stop: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "stop and keep what's done"),
+	),
+	KeepPartial: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "keep the partial transcript"),
+	),
+	Play: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "play/pause audio"),
+	),
+	Seek: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "play from selected segment"),
+	),
+	NextSegment: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next segment"),
+	),
+	PrevSegment: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous segment"),
+	),
+	CopySegment: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy segment with timestamp"),
+	),
+	ClipSegment: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "save segment audio clip"),
+	),
+	Rename: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "rename speakers"),
+	),
+	PrevSpeaker: key.NewBinding(
+		key.WithKeys("up", "shift+tab"),
+		key.WithHelp("↑", "previous speaker"),
+	),
+	NextSpeaker: key.NewBinding(
+		key.WithKeys("down", "tab"),
+		key.WithHelp("↓", "next speaker"),
+	),
+	PlaySample: key.NewBinding(
+		key.WithKeys("ctrl+p"),
+		key.WithHelp("ctrl+p", "play a sample of the speaker"),
+	),
+	ApplyNames: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "apply names"),
+	),
+	SkipNames: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "keep speaker labels"),
+	),
+	ToggleFormat: key.NewBinding(
+		key.WithKeys(" ", "x"),
+		key.WithHelp("space", "select format"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "save selected formats"),
+	),
+	Change: key.NewBinding(
+		key.WithKeys("right", "l", "enter", " "),
+		key.WithHelp("→/enter", "next value / toggle"),
+	),
+	Previous: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←", "previous value"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("esc", "o", "q"),
+		key.WithHelp("esc", "go back"),
+	),
+	Settings: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "settings"),
+	),
+	Presets: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "presets"),
+	),
+	ApplyPreset: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "apply preset"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "toggle help"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+	ForceQuit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// screenKeyMap adapts the bindings of one screen to help.KeyMap
+type screenKeyMap struct {
+	groups [][]key.Binding
+}
+
+func (s screenKeyMap) ShortHelp() []key.Binding {
+	var bindings []key.Binding
+	for _, group := range s.groups {
+		bindings = append(bindings, group...)
+	}
+	return bindings
+}
+
+func (s screenKeyMap) FullHelp() [][]key.Binding {
+	return s.groups
+}
+
+// helpKeys returns the bindings available on the current screen
+func (m model) helpKeys() help.KeyMap {
+	switch m.state {
+	case StateSelectFile:
+		if m.pathInput.Focused() {
+			return screenKeyMap{groups: [][]key.Binding{
+				{keys.Open, keys.LeavePath},
+				{keys.ForceQuit},
+			}}
+		}
+		if m.browser.Filtering() {
+			return screenKeyMap{groups: [][]key.Binding{
+				{keys.Up, keys.Down},
+				{keys.LeaveFilter, keys.ClearFilter},
+			}}
+		}
+		return screenKeyMap{groups: [][]key.Binding{
+			{keys.Up, keys.Down, keys.Top, keys.Bottom, keys.Open, keys.Back},
+			{keys.Filter, keys.SortFiles, keys.ToggleHidden},
+			{keys.TypePath, keys.Settings, keys.Presets},
+			{keys.Help, keys.Quit},
+		}}
+
+	case StateProcessing:
+		return screenKeyMap{groups: [][]key.Binding{
+			{keys.Stop},
+			{keys.Help, keys.Quit},
+		}}
+
+	case StateSettings:
+		return screenKeyMap{groups: [][]key.Binding{
+			{keys.Up, keys.Down},
+			{keys.Change, keys.Previous},
+			{keys.Close, keys.Help, keys.ForceQuit},
+		}}
+
+	case StatePresets:
+		return screenKeyMap{groups: [][]key.Binding{
+			{keys.Up, keys.Down},
+			{keys.ApplyPreset},
+			{keys.Close, keys.Help, keys.ForceQuit},
+		}}
+
+	case StateExport:
+		return screenKeyMap{groups: [][]key.Binding{
+			{keys.Up, keys.Down},
+			{keys.ToggleFormat, keys.Export},
+			{keys.Close, keys.Help, keys.ForceQuit},
+		}}
+
+	case StateSpeakers:
+		return screenKeyMap{groups: [][]key.Binding{
+			{keys.PrevSpeaker, keys.NextSpeaker, keys.PlaySample},
+			{keys.ApplyNames, keys.SkipNames, keys.ForceQuit},
+		}}
+	}
+
+	if m.error != "" && len(m.partial) > 0 {
+		return screenKeyMap{groups: [][]key.Binding{
+			{keys.KeepPartial, keys.NewFile},
+			{keys.Help, keys.Quit},
+		}}
+	}
+	return screenKeyMap{groups: [][]key.Binding{
+		{keys.Up, keys.Down, keys.Top, keys.Bottom},
+		{keys.NextSegment, keys.PrevSegment, keys.CopySegment, keys.ClipSegment},
+		{keys.Play, keys.Seek},
+		{keys.Save, keys.Copy, keys.Rename, keys.NewFile, keys.Settings},
+		{keys.Help, keys.Quit},
+	}}
+}
+
+// helpView renders the keybinding overlay for the current screen
+func (m model) helpView() string {
+	h := help.New()
+	h.Width = m.width - 8
+
+	return helpStyle.Render(
+		titleStyle.Render(tr("Keyboard Shortcuts")) + "\n\n" +
+			h.FullHelpView(m.helpKeys().FullHelp()) + "\n\n" +
+			subtitleStyle.Render(tr("Press ? or Esc to close")))
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// languageSample is the language detected in one stretch of audio
+type languageSample struct {
+	Start       float64 `json:"start"`
+	End         float64 `json:"end"`
+	Language    string  `json:"language"`
+	Probability float64 `json:"probability"`
+}
+
+const (
+	// languageWindow is how much audio Whisper looks at to detect a language
+	languageWindow = 30.0
+
+	// minLanguageSegment is the shortest segment whose language is detected
+	// on its own; shorter ones are too unreliable and keep the file's
+	minLanguageSegment = 2.0
+
+	// minLanguageProbability is the confidence needed to tag a segment with
+	// a language other than the file's
+	minLanguageProbability = 0.5
+
+	// detectionSamples is how many points --detect-language examines
+	detectionSamples = 5
+)
+
+// sampleWindows spreads count detection windows evenly over the audio
+func sampleWindows(duration float64, count int) []languageSample {
+	if duration <= languageWindow {
+		return []languageSample{{Start: 0, End: duration}}
+	}
+	windows := make([]languageSample, count)
+	for i := range windows {
+		center := duration * float64(2*i+1) / float64(2*count)
+		start := center - languageWindow/2
+		if start < 0 {
+			start = 0
+		}
+		if start > duration-languageWindow {
+			start = duration - languageWindow
+		}
+		windows[i] = languageSample{Start: start, End: start + languageWindow}
+	}
+	return windows
+}
+
+// wavDuration returns the length of a WAV file written by extractAudio
+// (16 kHz, 16-bit mono) from its size
+func wavDuration(path string) (float64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return float64(info.Size()-44) / (16000 * 2), nil
+}
+
+// detectLanguages runs the backend's language identification on each window
+// of the extracted audio
+func (p *AudioProcessor) detectLanguages(ctx context.Context, audioPath string, windows []languageSample) ([]languageSample, error) {
+	if len(windows) == 0 {
+		return nil, nil
+	}
+
+	windowsPath := filepath.Join(p.TempDir, "windows.json")
+	data, err := json.Marshal(windows)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(windowsPath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	outputPath := filepath.Join(p.TempDir, "languages.json")
+	scriptPath := filepath.Join(p.TempDir, "detect.py")
+	script := p.Backend.DetectScript(audioPath, windowsPath, outputPath, p.Config)
+	if err := os.WriteFile(scriptPath, []byte(script), 0644); err != nil {
+		return nil, err
+	}
+
+	output, err := exec.CommandContext(ctx, p.PythonPath, scriptPath).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("python language detection error: %s", output)
+	}
+
+	data, err = os.ReadFile(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read detected languages: %w", err)
+	}
+	var samples []languageSample
+	if err := json.Unmarshal(data, &samples); err != nil {
+		return nil, fmt.Errorf("failed to parse detected languages: %w", err)
+	}
+	return samples, nil
+}
+
+// detectFileLanguages samples count points of the input and detects the
+// language spoken at each, without transcribing it
+func detectFileLanguages(ctx context.Context, inputPath string, cfg Config, count int) ([]languageSample, error) {
+	backend, err := pipelineBackend(cfg.Backend)
+	if err != nil {
+		return nil, err
+	}
+	tempDir, err := os.MkdirTemp("", "audio_stt-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	processor := &AudioProcessor{InputPath: inputPath, TempDir: tempDir, Config: cfg, Backend: backend}
+	audioPath, err := processor.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := wavDuration(audioPath)
+	if err != nil {
+		return nil, err
+	}
+	return processor.detectLanguages(ctx, audioPath, sampleWindows(duration, count))
+}
+
+// languageCount is how often a language was detected
+type languageCount struct {
+	Language string
+	Samples  int
+}
+
+// countLanguages tallies the samples by language, most frequent first
+func countLanguages(samples []languageSample) []languageCount {
+	counts := map[string]int{}
+	for _, s := range samples {
+		counts[s.Language]++
+	}
+	var result []languageCount
+	for language, n := range counts {
+		result = append(result, languageCount{language, n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Samples != result[j].Samples {
+			return result[i].Samples > result[j].Samples
+		}
+		return result[i].Language < result[j].Language
+	})
+	return result
+}
+
+// dominantLanguage returns the language of more than half of the samples
+func dominantLanguage(samples []languageSample) (string, bool) {
+	counts := countLanguages(samples)
+	if len(counts) == 0 || counts[0].Samples*2 <= len(samples) {
+		return "", false
+	}
+	return counts[0].Language, true
+}
+
+// languageReport describes the detected languages, sample by sample
+func languageReport(samples []languageSample) string {
+	var b strings.Builder
+	for _, s := range samples {
+		fmt.Fprintf(&b, "    %s  %-3s %3.0f%%\n", formatTimestamp(s.Start, ".")[:8], s.Language, s.Probability*100)
+	}
+
+	var summary []string
+	for _, c := range countLanguages(samples) {
+		summary = append(summary, fmt.Sprintf("%s (%d of %d samples)", c.Language, c.Samples, len(samples)))
+	}
+	fmt.Fprintf(&b, "  detected %s\n", strings.Join(summary, ", "))
+	return b.String()
+}
+
+// tagLanguages detects the language of each segment for code-switched
+// audio. Runs of segments in another language than the file's are
+// transcribed again in their own language and tagged with it.
+func (p *AudioProcessor) tagLanguages(ctx context.Context, audioPath string, t *Transcript) error {
+	var windows []languageSample
+	for _, seg := range t.Segments {
+		if seg.End-seg.Start >= minLanguageSegment {
+			windows = append(windows, languageSample{Start: seg.Start, End: seg.End})
+		}
+	}
+	samples, err := p.detectLanguages(ctx, audioPath, windows)
+	if err != nil {
+		return err
+	}
+
+	next := 0
+	for i, seg := range t.Segments {
+		t.Segments[i].Language = t.Language
+		if seg.End-seg.Start < minLanguageSegment || next >= len(samples) {
+			continue
+		}
+		if s := samples[next]; s.Probability >= minLanguageProbability {
+			t.Segments[i].Language = s.Language
+		}
+		next++
+	}
+
+	var segments []Segment
+	for i := 0; i < len(t.Segments); {
+		language := t.Segments[i].Language
+		j := i
+		for j < len(t.Segments) && t.Segments[j].Language == language {
+			j++
+		}
+		run := t.Segments[i:j]
+		i = j
+
+		if language == t.Language {
+			segments = append(segments, run...)
+			continue
+		}
+		redone, err := p.transcribeClip(ctx, audioPath, run[0].Start, run[len(run)-1].End, language)
+		if err != nil {
+			return err
+		}
+		segments = append(segments, redone...)
+	}
+
+	texts := make([]string, len(segments))
+	for i, seg := range segments {
+		texts[i] = seg.Text
+	}
+	t.Segments = segments
+	t.Text = strings.Join(texts, " ")
+	return nil
+}
+
+// transcribeClip transcribes part of the extracted audio in the given
+// language, returning segments timed and tagged for the whole file
+func (p *AudioProcessor) transcribeClip(ctx context.Context, audioPath string, start, end float64, language string) ([]Segment, error) {
+	clipPath := filepath.Join(p.TempDir, "clip.wav")
+	cmd := exec.CommandContext(ctx, p.FFmpegPath,
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-to", fmt.Sprintf("%.3f", end),
+		"-i", audioPath,
+		"-c", "copy",
+		clipPath,
+		"-y",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("ffmpeg error: %s", output)
+	}
+
+	clip := *p
+	clip.Config.Language = language
+	clip.Hooks = PipelineHooks{}
+	transcript, err := clip.transcribeAudio(ctx, clipPath)
+	p.Stats.Transcribing += clip.Stats.Transcribing
+	if err != nil {
+		return nil, err
+	}
+
+	segments := transcript.Segments
+	for i := range segments {
+		segments[i].Start += start
+		segments[i].End += start
+		segments[i].Language = language
+		for j := range segments[i].Words {
+			segments[i].Words[j].Start += start
+			segments[i].Words[j].End += start
+		}
+	}
+	return segments, nil
+}
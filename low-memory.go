@@ -0,0 +1,65 @@
+package main
+
+import "fmt"
+
+// lowMemoryModel is the largest model --low-memory allows; tiny is kept
+// when asked for
+const lowMemoryModel = "base"
+
+// lowMemoryChunk is the seconds of audio a low-memory run reads and decodes
+// at a time: a few of Whisper's windows, a few megabytes of samples
+const lowMemoryChunk = 10 * whisperWindow
+
+// withLowMemory returns the settings a low-memory run actually uses: models
+// larger than lowMemoryModel are swapped for it
+func (c Config) withLowMemory() Config {
+	if c.LowMemory && c.Model != "tiny" {
+		c.Model = lowMemoryModel
+	}
+	return c
+}
+
+// lowMemoryScript builds a transcription script that reads the audio from
+// the WAV file lowMemoryChunk seconds at a time and appends each segment
+// to the output file as it is decoded, so neither the whole recording nor
+// the whole transcript is held in memory. load is Python that loads the
+// model; decode defines decode(audio, language), which returns the
+// detected language and the chunk's segments as dicts.
+func lowMemoryScript(audioPath, outputPath string, cfg Config, load, decode string) string {
+	return `
+import json
+import wave
+
+import numpy as np
+` + load + decode + fmt.Sprintf(`
+# The first chunk's language is kept for the rest, as for a whole file
+language = %s
+first = True
+with wave.open(%s, "rb") as f, open(%s, "w", encoding="utf-8") as out:
+    rate = f.getframerate()
+    offset = 0.0
+    out.write('{"segments": [')
+    while True:
+        frames = f.readframes(int(%g * rate))
+        if not frames:
+            break
+        audio = np.frombuffer(frames, np.int16).astype(np.float32) / 32768.0
+        print("Transcribing from %%.0f seconds..." %% offset, flush=True)
+        detected, segments = decode(audio, language)
+        language = language or detected
+        for s in segments:
+            s["start"] += offset
+            s["end"] += offset
+            for w in s["words"]:
+                w["start"] += offset
+                w["end"] += offset
+            print(%q + json.dumps(s), flush=True)
+            out.write(("" if first else ",") + json.dumps(s, ensure_ascii=False))
+            out.flush()
+            first = False
+        offset += len(audio) / rate
+        del audio, segments
+    out.write('], "language": ' + json.dumps(language or "") + '}')
+print("Transcription completed")
+`, pythonLanguage(cfg.Language), pythonPath(audioPath), pythonPath(outputPath), lowMemoryChunk, segmentLinePrefix)
+}
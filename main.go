@@ -1,14 +1,17 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/charmbracelet/bubbles/filepicker"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -17,149 +20,285 @@ const (
 	StateSelectFile = iota
 	StateProcessing
 	StateComplete
-)
-
-var (
-	titleStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Background(lipgloss.Color("#7D56F4")).
-			Padding(0, 1)
-
-	subtitleStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#999999"))
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FF5555"))
-
-	successStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#50FA7B"))
-
-	transcriptionStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#F8F8F2")).
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("#6272A4")).
-				Padding(1, 2).
-				Width(80)
+	StateSettings
+	StateExport
+	StatePresets
+	StateSpeakers
 )
 
 type model struct {
-	state         int
-	filepicker    filepicker.Model
-	spinner       spinner.Model
-	selectedFile  string
-	transcription string
-	error         string
-	width         int
-	height        int
-	scrollOffset  int
-	maxScroll     int
+	state           int
+	prevState       int
+	config          Config
+	browser         fileBrowser
+	pathInput       textinput.Model
+	inputError      string
+	spinner         spinner.Model
+	selectedFile    string
+	demoFile        string // sample clip transcribed by the fake backend
+	run             *processingRun
+	partial         []Segment // segments finished before a failed or stopped run
+	transcript      *Transcript
+	transcription   string
+	player          *audioPlayer
+	playingSegment  int
+	selectedSegment int
+	error           string
+	status          string
+	settingsCursor  int
+	settingsError   string
+	exportCursor    int
+	presetCursor    int
+	speakerCursor   int
+	speakerLabels   []string
+	speakerInputs   []textinput.Model
+	exportFormats   string
+	showHelp        bool
+	updateNotice    string
+	width           int
+	height          int
+	scrollOffset    int
+	maxScroll       int
 }
 
-func initialModel() model {
-	// Initialize file picker
-	fp := filepicker.New()
-	fp.AllowedTypes = []string{".mp4", ".avi", ".mov", ".mkv", ".webm", ".mp3", ".wav", ".m4a", ".flac"}
-	fp.CurrentDirectory, _ = os.Getwd()
+func initialModel(cfg Config) model {
+	// Initialize file browser in the last used directory
+	browser := newFileBrowser(startDirectory(cfg), 24)
 
 	// Initialize spinner
 	s := spinner.New()
-	s.Spinner = spinner.Dot
+	s.Spinner = spinnerType
 
 	return model{
-		state:        StateSelectFile,
-		filepicker:   fp,
-		spinner:      s,
-		width:        80,
-		height:       24,
-		scrollOffset: 0,
-		maxScroll:    0,
+		state:          StateSelectFile,
+		config:         cfg,
+		player:         &audioPlayer{},
+		run:            &processingRun{},
+		playingSegment: -1,
+		browser:        browser,
+		pathInput:      newPathInput(),
+		spinner:        s,
+		width:          80,
+		height:         24,
+		scrollOffset:   0,
+		maxScroll:      0,
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return m.filepicker.Init()
+	if m.state == StateProcessing {
+		return tea.Batch(m.browser.Init(), m.spinner.Tick, m.startProcessing())
+	}
+	return tea.Batch(m.browser.Init(), checkUpdateCmd(m.config))
+}
+
+// startDirectory returns the remembered directory if it still exists,
+// otherwise the working directory
+func startDirectory(cfg Config) string {
+	if cfg.LastDirectory != "" {
+		if stat, err := os.Stat(cfg.LastDirectory); err == nil && stat.IsDir() {
+			return cfg.LastDirectory
+		}
+	}
+	cwd, _ := os.Getwd()
+	return cwd
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return m, tea.Quit
-		case "enter":
-			// Handle Enter key press when in complete state
-			if m.state == StateComplete {
-				// Reset the model to initial state for new file selection
-				m.state = StateSelectFile
-				m.selectedFile = ""
-				m.transcription = ""
-				m.error = ""
-				m.scrollOffset = 0
-				m.maxScroll = 0
+		// The help overlay swallows keys until it is closed
+		if m.showHelp {
+			switch {
+			case key.Matches(msg, keys.ForceQuit):
+				return m, tea.Quit
+			case key.Matches(msg, keys.Help), msg.String() == "esc", msg.String() == "q":
+				m.showHelp = false
+			}
+			return m, nil
+		}
 
-				// Reinitialize the filepicker
-				fp := filepicker.New()
-				fp.AllowedTypes = []string{".mp4", ".avi", ".mov", ".mkv", ".webm", ".mp3", ".wav", ".m4a", ".flac"}
-				fp.CurrentDirectory, _ = os.Getwd()
-				fp.Height = m.height - 4
-				m.filepicker = fp
+		if m.state == StateSelectFile {
+			// Typed or dropped paths go to the path input instead of the picker
+			if m.pathInput.Focused() {
+				return m.updatePathInput(msg)
+			}
+			if m.browser.Filtering() {
+				return m.updateBrowser(msg)
+			}
+			if isDroppedPathStart(msg) {
+				focusCmd := m.pathInput.Focus()
+				var inputCmd tea.Cmd
+				m.pathInput, inputCmd = m.pathInput.Update(msg)
+				return m, tea.Batch(focusCmd, inputCmd)
+			}
+		}
 
-				return m, m.filepicker.Init()
+		// Names may contain a question mark
+		if key.Matches(msg, keys.Help) && m.state != StateSpeakers {
+			m.showHelp = true
+			return m, nil
+		}
+		if m.state == StateSettings {
+			return m.updateSettings(msg)
+		}
+		if m.state == StateExport {
+			return m.updateExport(msg)
+		}
+		if m.state == StatePresets {
+			return m.updatePresets(msg)
+		}
+		if m.state == StateSpeakers {
+			return m.updateSpeakers(msg)
+		}
+
+		switch {
+		case key.Matches(msg, keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, keys.Stop):
+			if m.state == StateProcessing {
+				m.run.stop()
+				return m, nil
+			}
+		case key.Matches(msg, keys.KeepPartial):
+			if m.state == StateComplete && m.error != "" && len(m.partial) > 0 {
+				transcript := partialTranscript(m.partial, m.selectedFile, m.runConfig())
+				m.error, m.partial = "", nil
+				return m, func() tea.Msg { return processCompleteMsg{transcript: transcript} }
+			}
+		case key.Matches(msg, keys.TypePath):
+			if m.state == StateSelectFile {
+				return m, m.pathInput.Focus()
+			}
+		case key.Matches(msg, keys.Settings):
+			if m.state == StateSelectFile || m.state == StateComplete {
+				return m.openSettings(), nil
 			}
-		case "up", "k":
+		case key.Matches(msg, keys.Presets):
+			if m.state == StateSelectFile {
+				return m.openPresets(), nil
+			}
+		case key.Matches(msg, keys.Save):
+			if m.state == StateComplete && m.transcript != nil {
+				return m.openExport(), nil
+			}
+		case key.Matches(msg, keys.Copy):
+			if m.state == StateComplete && m.transcript != nil {
+				m.status = m.copyTranscript()
+				return m, nil
+			}
+		case key.Matches(msg, keys.NewFile):
+			if m.state == StateComplete {
+				return m.newFile()
+			}
+		case key.Matches(msg, keys.Play):
+			if m.state == StateComplete && m.error == "" {
+				return m.togglePlayback()
+			}
+		case key.Matches(msg, keys.Seek):
+			if m.state == StateComplete && m.error == "" {
+				return m.seekToSelectedSegment()
+			}
+			// Enter still returns to the picker after an error
+			if m.state == StateComplete {
+				return m.newFile()
+			}
+		case key.Matches(msg, keys.NextSegment):
+			if m.state == StateComplete && m.error == "" {
+				return m.selectSegment(1), nil
+			}
+		case key.Matches(msg, keys.PrevSegment):
+			if m.state == StateComplete && m.error == "" {
+				return m.selectSegment(-1), nil
+			}
+		case key.Matches(msg, keys.CopySegment):
+			if m.state == StateComplete && m.transcript != nil {
+				m.status = m.copySegment()
+				return m, nil
+			}
+		case key.Matches(msg, keys.ClipSegment):
+			if m.state == StateComplete && m.transcript != nil {
+				m.status = m.clipSegment()
+				return m, nil
+			}
+		case key.Matches(msg, keys.Rename):
+			if m.state == StateComplete && m.transcript != nil && len(m.transcript.speakers()) > 0 {
+				return m.openSpeakers()
+			}
+		case key.Matches(msg, keys.Up):
 			if m.state == StateComplete && m.transcription != "" {
 				if m.scrollOffset > 0 {
 					m.scrollOffset--
 				}
 			}
-		case "down", "j":
+		case key.Matches(msg, keys.Down):
 			if m.state == StateComplete && m.transcription != "" {
 				if m.scrollOffset < m.maxScroll {
 					m.scrollOffset++
 				}
 			}
-		case "home":
+		case key.Matches(msg, keys.Top):
 			if m.state == StateComplete && m.transcription != "" {
 				m.scrollOffset = 0
 			}
-		case "end":
+		case key.Matches(msg, keys.Bottom):
 			if m.state == StateComplete && m.transcription != "" {
 				m.scrollOffset = m.maxScroll
 			}
 		}
 
+	case tea.MouseMsg:
+		if m.state == StateComplete && !m.showHelp {
+			return m.handleMouse(msg)
+		}
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		if m.state == StateSelectFile {
-			m.filepicker.Height = msg.Height - 4
+		m.browser.Width = msg.Width
+		m.browser.Height = browserHeight(msg.Height)
+		if m.state == StateComplete {
+			m = m.updateMaxScroll()
 		}
 
 	case processCompleteMsg:
 		m.state = StateComplete
-		m.transcription = string(msg)
+		m.transcript = msg.transcript
+		m.transcription = msg.transcript.Text
+		if m.transcription == "" {
+			m.transcription = tr("No speech detected in the audio file.")
+		}
 		m.scrollOffset = 0
+		m.selectedSegment = 0
+		m = m.updateMaxScroll()
 
-		// Calculate max scroll based on transcription length and available space
-		transcriptionHeight := m.height - 10 // Leave space for title and instructions
-		if transcriptionHeight < 5 {
-			transcriptionHeight = 5
+		// Diarized transcripts get their speakers named first
+		if len(m.transcript.speakers()) > 0 {
+			return m.openSpeakers()
 		}
+		return m, nil
 
-		// Wrap text and count lines
-		wrappedText := m.wrapText(m.transcription, m.width-8) // Account for padding and border
-		totalLines := len(strings.Split(wrappedText, "\n"))
+	case playbackTickMsg:
+		if msg.generation == m.player.generation && m.player.Playing() && m.state == StateComplete {
+			m = m.updatePlayback()
+			return m, playbackTick(msg.generation)
+		}
+		return m, nil
 
-		if totalLines > transcriptionHeight {
-			m.maxScroll = totalLines - transcriptionHeight
-		} else {
-			m.maxScroll = 0
+	case playbackEndedMsg:
+		if msg.generation == m.player.generation {
+			m.player.Reset()
+			m.playingSegment = -1
 		}
+		return m, nil
 
+	case updateAvailableMsg:
+		m.updateNotice = tr("%s available: run stt-cli self-update", msg.version)
 		return m, nil
 
 	case processErrorMsg:
-		m.error = string(msg)
+		m.error = msg.err
+		m.partial = msg.partial
 		m.state = StateComplete
 		return m, nil
 
@@ -169,75 +308,180 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.spinner, cmd = m.spinner.Update(msg)
 			return m, cmd
 		}
+
+	case dirLoadedMsg, durationMsg, previewRequestMsg, previewMsg:
+		// Background listing results are delivered whatever the screen
+		var cmd tea.Cmd
+		m.browser, cmd = m.browser.Update(msg)
+		return m, cmd
 	}
 
 	var cmd tea.Cmd
 	switch m.state {
 	case StateSelectFile:
-		m.filepicker, cmd = m.filepicker.Update(msg)
-
-		// Check if user selected a file
-		if didSelect, path := m.filepicker.DidSelectFile(msg); didSelect {
-			m.selectedFile = path
-			m.state = StateProcessing
-			return m, tea.Batch(m.spinner.Tick, m.startProcessing())
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			return m.updateBrowser(msg)
 		}
 
+		// Let the text fields animate their cursors
+		var filterCmd tea.Cmd
+		m.pathInput, cmd = m.pathInput.Update(msg)
+		m.browser.filter, filterCmd = m.browser.filter.Update(msg)
+		cmd = tea.Batch(cmd, filterCmd)
+
 	case StateProcessing:
 		m.spinner, cmd = m.spinner.Update(msg)
+
+	case StateSpeakers:
+		// Let the name field animate its cursor
+		m.speakerInputs[m.speakerCursor], cmd = m.speakerInputs[m.speakerCursor].Update(msg)
 	}
 
 	return m, cmd
 }
 
 func (m model) View() string {
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.content())
+}
+
+// content renders the current screen before it is centered in the window
+func (m model) content() string {
 	var content string
 
 	switch m.state {
 	case StateSelectFile:
-		content = fmt.Sprintf("%s\n\n%s\n\n%s",
-			titleStyle.Render("Speech-to-Text CLI"),
-			subtitleStyle.Render("Select a video or audio file to transcribe:"),
-			m.filepicker.View())
+		inputLine := m.pathInput.View()
+		if m.inputError != "" {
+			inputLine += "  " + errorStyle.Render(m.inputError)
+		}
+		title := titleStyle.Render("Speech-to-Text CLI")
+		if m.updateNotice != "" {
+			title += "  " + subtitleStyle.Render(m.updateNotice)
+		}
+		content = fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s",
+			title,
+			subtitleStyle.Render(tr("Select a video or audio file to transcribe (press '?' for help):")),
+			m.browser.View(),
+			inputLine)
 
 	case StateProcessing:
+		progress := tr("Extracting audio and transcribing... This may take a few minutes...")
+		if m.run.stopped() {
+			progress = tr("Stopping...")
+		} else if done := len(m.run.finished()); done > 0 {
+			progress = tr("%d segments transcribed • Press 'x' to stop and keep them", done)
+		}
 		content = fmt.Sprintf("%s\n\n%s %s\n%s\n\n%s",
 			titleStyle.Render("Speech-to-Text CLI"),
 			m.spinner.View(),
-			"Processing audio...",
-			subtitleStyle.Render(fmt.Sprintf("File: %s", filepath.Base(m.selectedFile))),
-			subtitleStyle.Render("Extracting audio and transcribing... This may take a few minutes..."))
+			tr("Processing audio..."),
+			subtitleStyle.Render(tr("File: %s", filepath.Base(m.selectedFile))),
+			subtitleStyle.Render(progress))
+
+	case StateSettings:
+		content = m.settingsView()
+
+	case StateExport:
+		content = m.exportView()
+
+	case StatePresets:
+		content = m.presetsView()
+
+	case StateSpeakers:
+		content = m.speakersView()
 
 	case StateComplete:
 		if m.error != "" {
+			heading := tr("Error occurred:")
+			if m.run.stopped() {
+				heading = tr("Transcription stopped:")
+			}
 			content = fmt.Sprintf("%s\n\n%s\n\n%s",
 				titleStyle.Render("Speech-to-Text CLI"),
-				errorStyle.Render("Error occurred:"),
+				errorStyle.Render(heading),
 				errorStyle.Render(m.error))
+			if len(m.partial) > 0 {
+				content += "\n\n" + subtitleStyle.Render(tr("%d segments were finished • Press 'p' to keep them as a partial transcript, Enter for another file", len(m.partial)))
+			}
 		} else {
 			scrollInstructions := ""
 			if m.maxScroll > 0 {
-				scrollInstructions = subtitleStyle.Render(fmt.Sprintf("Use ↑/↓ or j/k to scroll • Line %d-%d of %d • Press Enter for another file • Press 'q' to exit",
+				scrollInstructions = subtitleStyle.Render(tr("Use ↑/↓ or j/k to scroll • Line %d-%d of %d • [/] segments • Space to play • Press 's' to save, 'c' to copy • Press 'n' for another file • Press 'q' to exit",
 					m.scrollOffset+1,
-					min(m.scrollOffset+(m.height-10), len(strings.Split(m.wrapText(m.transcription, m.width-8), "\n"))),
-					len(strings.Split(m.wrapText(m.transcription, m.width-8), "\n"))))
+					min(m.scrollOffset+m.transcriptionHeight(), len(m.transcriptLines())),
+					len(m.transcriptLines())))
 			} else {
-				scrollInstructions = subtitleStyle.Render("Press Enter for another file • Press 'q' to exit")
+				scrollInstructions = subtitleStyle.Render(tr("[/] segments • Space to play • Press 's' to save, 'c' to copy • Press 'n' for another file • Press 'q' to exit"))
+			}
+			if m.status != "" {
+				scrollInstructions = successStyle.Render(m.status) + "\n" + scrollInstructions
+			}
+
+			transcription := m.renderScrollableTranscription()
+			if lines := m.analysisLines(); len(lines) > 0 {
+				transcription += "\n" + strings.Join(lines, "\n")
 			}
 
-			content = fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s",
+			heading := successStyle.Render(tr("Transcription completed"))
+			if m.transcript != nil && m.transcript.Partial {
+				heading = errorStyle.Render(tr("Partial transcript: stopped at %s", formatClock(m.transcript.duration())))
+			}
+
+			// The buttons must stay on the last line for mouse hit-testing
+			content = fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s\n\n%s",
 				titleStyle.Render("Speech-to-Text CLI"),
-				successStyle.Render("Transcription completed"),
-				m.renderScrollableTranscription(),
-				scrollInstructions)
+				heading,
+				transcription,
+				scrollInstructions,
+				m.buttonsView())
 		}
 	}
 
-	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+	if m.showHelp {
+		content = m.helpView()
+	}
+
+	return content
 }
 
-// wrapText wraps text to fit within the specified width
-func (m model) wrapText(text string, width int) string {
+// transcriptionHeight returns how many transcript lines fit on screen
+func (m model) transcriptionHeight() int {
+	// Leave space for title, instructions, buttons and the analysis panel
+	return max(5, m.height-12-len(m.analysisLines()))
+}
+
+// updateMaxScroll recalculates the scroll limit for the current window size
+func (m model) updateMaxScroll() model {
+	m.maxScroll = max(0, len(m.transcriptLines())-m.transcriptionHeight())
+	m.scrollOffset = min(m.scrollOffset, m.maxScroll)
+	return m
+}
+
+// newFile resets the model to initial state for new file selection
+func (m model) newFile() (tea.Model, tea.Cmd) {
+	m.player.Reset()
+	m.playingSegment = -1
+	m.state = StateSelectFile
+	m.selectedFile = ""
+	m.transcript = nil
+	m.transcription = ""
+	m.error = ""
+	m.partial = nil
+	m.status = ""
+	m.scrollOffset = 0
+	m.maxScroll = 0
+
+	// Reinitialize the file browser
+	m.browser = newFileBrowser(startDirectory(m.config), m.height)
+	m.browser.Width = m.width
+
+	return m, m.browser.Init()
+}
+
+// wrapText wraps text to fit within the specified width in terminal
+// columns. Words wider than a line, and CJK text, which has no spaces
+// between words, are broken between characters; never inside one.
+func wrapText(text string, width int) string {
 	if width <= 0 {
 		return text
 	}
@@ -249,21 +493,40 @@ func (m model) wrapText(text string, width int) string {
 
 	var lines []string
 	var currentLine string
+	lineWidth := 0
 
 	for _, word := range words {
-		testLine := currentLine
-		if testLine != "" {
-			testLine += " "
+		wordWidth := textWidth(word)
+		gap := 0
+		if currentLine != "" {
+			gap = 1
 		}
-		testLine += word
 
-		if len(testLine) <= width {
-			currentLine = testLine
-		} else {
-			if currentLine != "" {
-				lines = append(lines, currentLine)
+		if lineWidth+gap+wordWidth <= width {
+			currentLine += strings.Repeat(" ", gap) + word
+			lineWidth += gap + wordWidth
+			continue
+		}
+
+		if wordWidth <= width && !hasWideText(word) {
+			lines = append(lines, currentLine)
+			currentLine, lineWidth = word, wordWidth
+			continue
+		}
+
+		// Fill the rest of the line and carry on with the next ones
+		if currentLine != "" && lineWidth+gap < width {
+			currentLine += " "
+			lineWidth++
+		}
+		for _, cluster := range graphemes(word) {
+			clusterWidth := textWidth(cluster)
+			if currentLine != "" && lineWidth+clusterWidth > width {
+				lines = append(lines, strings.TrimRight(currentLine, " "))
+				currentLine, lineWidth = "", 0
 			}
-			currentLine = word
+			currentLine += cluster
+			lineWidth += clusterWidth
 		}
 	}
 
@@ -276,14 +539,10 @@ func (m model) wrapText(text string, width int) string {
 
 // renderScrollableTranscription renders the transcription with scrolling
 func (m model) renderScrollableTranscription() string {
-	transcriptionHeight := m.height - 10 // Leave space for title and instructions
-	if transcriptionHeight < 5 {
-		transcriptionHeight = 5
-	}
+	transcriptionHeight := m.transcriptionHeight()
 
 	// Wrap text to fit the display width
-	wrappedText := m.wrapText(m.transcription, m.width-8) // Account for padding and border
-	lines := strings.Split(wrappedText, "\n")
+	lines := m.transcriptLines()
 
 	// Extract visible lines based on scroll offset
 	startLine := m.scrollOffset
@@ -294,14 +553,21 @@ func (m model) renderScrollableTranscription() string {
 		endLine = len(lines)
 	}
 
-	visibleText := strings.Join(lines[startLine:endLine], "\n")
+	// Mark the selected segment and style the rest of the line
+	var visibleLines []string
+	for _, line := range lines[startLine:endLine] {
+		marker := "  "
+		if line.segment >= 0 && line.segment == m.selectedSegment {
+			marker = selectedMarker
+		}
+		visibleLines = append(visibleLines, marker+m.renderTranscriptLine(line))
+	}
 
 	// Pad with empty lines if needed to maintain consistent height
-	visibleLines := strings.Split(visibleText, "\n")
 	for len(visibleLines) < transcriptionHeight {
 		visibleLines = append(visibleLines, "")
 	}
-	visibleText = strings.Join(visibleLines, "\n")
+	visibleText := strings.Join(visibleLines, "\n")
 
 	return transcriptionStyle.
 		Width(m.width - 4).
@@ -324,26 +590,114 @@ func max(a, b int) int {
 	return b
 }
 
-type processCompleteMsg string
-type processErrorMsg string
+type processCompleteMsg struct {
+	transcript *Transcript
+}
+type processErrorMsg struct {
+	err     string
+	partial []Segment // segments finished before the run stopped
+}
+
+// runConfig returns the settings the selected file is transcribed with
+func (m model) runConfig() Config {
+	cfg := m.config
+	if m.selectedFile == m.demoFile {
+		cfg.Backend = fakeBackend.Name
+	}
+	return cfg
+}
 
 func (m model) startProcessing() tea.Cmd {
+	cfg := m.runConfig()
+	ctx := m.run.start()
 	return func() tea.Msg {
-		transcription, err := processAudioSTT(m.selectedFile)
+		defer m.run.finish()
+		transcript, _, err := runPipeline(ctx, m.selectedFile, cfg, PipelineHooks{Segment: m.run.add})
 		if err != nil {
-			return processErrorMsg(err.Error())
+			return processErrorMsg{err: err.Error(), partial: m.run.finished()}
 		}
-		return processCompleteMsg(transcription)
+		return processCompleteMsg{transcript: transcript}
+	}
+}
+
+// updateBrowser passes a key to the file browser and starts processing
+// when a file is chosen
+func (m model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.browser, cmd = m.browser.Update(msg)
+
+	if path, ok := m.browser.DidSelectFile(); ok {
+		// Remember the directory for next time; failing to save isn't fatal
+		m.config.LastDirectory = filepath.Dir(path)
+		m.config.save()
+
+		m.selectedFile = path
+		m.state = StateProcessing
+		return m, tea.Batch(m.spinner.Tick, m.startProcessing())
+	}
+
+	return m, cmd
+}
+
+// saveTranscript writes the transcript next to the input file in the
+// configured output formats and returns a status message
+func (m model) saveTranscript() string {
+	saved, err := writeTranscripts(m.transcript, transcriptPath(m.selectedFile, "", ""), m.config)
+	if err != nil {
+		return err.Error()
 	}
+	return tr("Saved %s", strings.Join(saved, ", "))
 }
 
 func main() {
+	cfg, err := loadConfig()
+	applyTheme(cfg.Theme)
+	setUILanguage(cfg.UILanguage)
+
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	fs := flag.NewFlagSet("stt-cli", flag.ContinueOnError)
+	noColor := fs.Bool("no-color", false, "disable colors")
+	ascii := fs.Bool("ascii", false, "use ASCII borders and spinner")
+	plain := fs.Bool("plain", false, "line-oriented output without the full-screen interface")
+	fs.Usage = func() { fmt.Print(usageText) }
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+	if *noColor || *ascii {
+		cfg.Theme.NoColor = cfg.Theme.NoColor || *noColor
+		cfg.Theme.ASCII = cfg.Theme.ASCII || *ascii
+		applyTheme(cfg.Theme)
+	}
+
 	fmt.Println("Speech-to-Text CLI")
 	fmt.Println("A tool to extract audio and transcribe speech from video/audio files")
 	fmt.Println("")
 
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	if err != nil {
+		fmt.Printf("Warning: %v (using default settings)\n", err)
+	}
+
+	if *plain || isDumbTerminal() {
+		if err := runPlain(cfg); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	p := tea.NewProgram(initialModel(cfg), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	finalModel, err := p.Run()
+	if err != nil {
 		log.Fatal(err)
 	}
+
+	// Don't leave ffplay running after quitting
+	if m, ok := finalModel.(model); ok {
+		m.player.Stop()
+	}
 }
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// manifestExtension is added to the transcript's base name for the manifest
+const manifestExtension = ".manifest.json"
+
+// runManifest records how a transcript was made: the tools and their
+// versions, the settings and what they passed to the model, the input's
+// hash and the timings, so the run can be reproduced and audited
+type runManifest struct {
+	Tool    string    `json:"tool"`
+	Version string    `json:"version"`
+	Created time.Time `json:"created"`
+
+	Input manifestFile   `json:"input"`
+	Media *manifestMedia `json:"media,omitempty"` // nil without ffprobe
+
+	// Versions of ffmpeg, Python and the backend's package
+	Tools map[string]string `json:"tools"`
+
+	Backend       string         `json:"backend"`
+	Model         string         `json:"model"`
+	Decoding      map[string]any `json:"decoding"`      // keyword arguments of model.transcribe
+	Preprocessing string         `json:"preprocessing"` // ffmpeg filter chain, empty for none
+	Config        Config         `json:"config"`
+
+	// Files the settings refer to, whose contents change the result
+	Corrections *manifestFile `json:"corrections,omitempty"`
+	Template    *manifestFile `json:"template,omitempty"`
+
+	Timings manifestTimings `json:"timings"`
+
+	Language string         `json:"language,omitempty"` // as transcribed
+	Partial  bool           `json:"partial,omitempty"`
+	Outputs  []manifestFile `json:"outputs"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// manifestFile identifies a file by its contents
+type manifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// manifestMedia is what ffprobe found in the input
+type manifestMedia struct {
+	Format     string   `json:"format"`
+	Duration   float64  `json:"duration"`
+	Codecs     []string `json:"audio_codecs"`
+	SampleRate int      `json:"sample_rate"`
+	Channels   int      `json:"channels"`
+}
+
+// manifestTimings are the run's wall-clock times and resource use
+type manifestTimings struct {
+	Started       time.Time `json:"started"`
+	Finished      time.Time `json:"finished"`
+	AudioSeconds  float64   `json:"audio_seconds,omitempty"`
+	Transcription float64   `json:"transcription_seconds,omitempty"`
+	PeakMemory    uint64    `json:"peak_memory_bytes,omitempty"`
+}
+
+// hashFile describes a file by its size and SHA-256. Remote inputs and
+// files that can't be read are described by their path alone.
+func hashFile(path string) manifestFile {
+	file := manifestFile{Path: path}
+	f, err := os.Open(path)
+	if err != nil {
+		return file
+	}
+	defer f.Close()
+	h := sha256.New()
+	if file.Size, err = io.Copy(h, f); err != nil {
+		return manifestFile{Path: path}
+	}
+	file.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return file
+}
+
+// toolVersion returns the first line a command prints, or "unknown"
+func toolVersion(name string, args ...string) string {
+	output, err := exec.Command(name, args...).Output()
+	if err != nil {
+		return "unknown"
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return line
+}
+
+// decodingParameters lists what the backend's script passes to
+// model.transcribe for the settings
+func decodingParameters(cfg Config, backend Backend) map[string]any {
+	task := "transcribe"
+	if cfg.Translate {
+		task = "translate"
+	}
+	decoding := map[string]any{
+		"language":        cfg.Language,
+		"task":            task,
+		"word_timestamps": true,
+	}
+	if cfg.Language == "auto" {
+		decoding["language"] = nil // detected by the model
+	}
+	if len(cfg.BoostPhrases) > 0 {
+		switch backend.Boost {
+		case BoostPrompt:
+			decoding["initial_prompt"] = boostPrompt(cfg.BoostPhrases)
+		case BoostHotwords:
+			decoding["hotwords"] = strings.Join(cfg.BoostPhrases, ", ")
+		}
+	}
+	return decoding
+}
+
+// newRunManifest describes a run of the pipeline on input before it starts,
+// looking up the tools' versions
+func newRunManifest(input string, cfg Config, started time.Time) *runManifest {
+	cfg = cfg.withLowMemory()
+	backend, _ := pipelineBackend(cfg.Backend) // validated with the config
+	processor := &AudioProcessor{Config: cfg}
+
+	// Presets and UI state don't affect the transcript
+	settings := cfg
+	settings.Presets = nil
+	settings.LastDirectory = ""
+	settings.History = HistoryConfig{}
+
+	m := &runManifest{
+		Tool:    "stt-cli",
+		Version: version,
+		Created: time.Now().UTC(),
+		Input:   manifestFile{Path: input},
+		Tools: map[string]string{
+			"python":        toolVersion("python", "--version"),
+			backend.Package: toolVersion("python", "-c", fmt.Sprintf("import importlib.metadata as m; print(m.version(%q))", backend.Package)),
+		},
+		Backend:       backend.Name,
+		Model:         cfg.Model,
+		Decoding:      decodingParameters(cfg, backend),
+		Preprocessing: processor.audioFilters(),
+		Config:        settings,
+		Timings:       manifestTimings{Started: started.UTC()},
+	}
+	if !isRemoteInput(input) {
+		m.Input = hashFile(input)
+	}
+	if ffmpegPath, err := findFFmpeg(); err == nil {
+		m.Tools["ffmpeg"] = toolVersion(ffmpegPath, "-version")
+	}
+	if cfg.Corrections != "" {
+		file := hashFile(cfg.Corrections)
+		m.Corrections = &file
+	}
+	if cfg.Template != "" {
+		file := hashFile(cfg.Template)
+		m.Template = &file
+	}
+	return m
+}
+
+// setMedia records the probed input
+func (m *runManifest) setMedia(info *MediaInfo) {
+	if info != nil {
+		m.Media = &manifestMedia{info.FormatName, info.Duration, info.AudioCodecs, info.SampleRate, info.Channels}
+	}
+}
+
+// finish records the outcome of the run
+func (m *runManifest) finish(t *Transcript, stats RunStats, outputs []string, err error) {
+	m.Timings.Finished = time.Now().UTC()
+	m.Timings.AudioSeconds = stats.AudioDuration
+	m.Timings.Transcription = stats.Transcribing.Seconds()
+	m.Timings.PeakMemory = stats.PeakMemory
+	if t != nil {
+		m.Language = t.Language
+		m.Partial = t.Partial
+	}
+	for _, path := range outputs {
+		m.Outputs = append(m.Outputs, hashFile(path))
+	}
+	if err != nil {
+		m.Error = err.Error()
+	}
+}
+
+// write saves the manifest as indented JSON
+func (m *runManifest) write(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
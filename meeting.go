@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// summarySentences is how many sentences the minutes' summary has
+	summarySentences = 5
+
+	// speakerHighlights is how many sentences are quoted for each speaker
+	speakerHighlights = 2
+
+	// minKeySentence is the fewest words a sentence needs to be quoted
+	minKeySentence = 6
+)
+
+// Phrases that mark a sentence as a decision or an action item. Decisions
+// are checked first, so "we agreed that Sam will..." is a decision.
+var (
+	decisionPattern = regexp.MustCompile(`(?i)\b(we(?: have|'ve)? (?:decided|agreed)|decision is|it's decided|agreed|let's go with|we're going with|we are going with|settled on|approved)\b`)
+	actionPattern   = regexp.MustCompile(`(?i)\b(action items?|i'll|i will|we'll|we will|we need to|you need to|needs? to be|follow up|take care of|to-?do|can you|could you|will you|let me)\b`)
+)
+
+// applyMode sets up the pipeline for a kind of recording. Unlike a preset,
+// a mode adds its output format to the configured ones.
+func (c *Config) applyMode(mode string) error {
+	switch mode {
+	case "meeting":
+		c.Diarize = true
+		c.Capitalize = true
+		if c.OutputFormat == "" {
+			c.OutputFormat = "minutes"
+		} else if !formatSelected(c.OutputFormat, "minutes") {
+			c.OutputFormat += ",minutes"
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown mode %q (available: meeting)", mode)
+}
+
+// minuteItem is a sentence quoted in the minutes
+type minuteItem struct {
+	Start   float64
+	Speaker string
+	Text    string
+}
+
+// attendee is a speaker of the meeting with their speaking time
+type attendee struct {
+	Name       string
+	Seconds    float64
+	Highlights []minuteItem
+}
+
+// meetingMinutes is the structured summary of a meeting transcript
+type meetingMinutes struct {
+	Title     string
+	Duration  float64
+	Attendees []attendee
+	Summary   []minuteItem
+	Decisions []minuteItem
+	Actions   []minuteItem
+}
+
+// transcriptSentences splits the transcript into sentences. A sentence may
+// span segments but not speakers; it is timed by the segment it starts in.
+func transcriptSentences(t *Transcript) []minuteItem {
+	var sentences []minuteItem
+	var current minuteItem
+	var words []string
+	flush := func() {
+		if len(words) > 0 {
+			current.Text = strings.Join(words, " ")
+			sentences = append(sentences, current)
+		}
+		words = nil
+	}
+
+	for _, seg := range t.Segments {
+		if len(words) > 0 && seg.Speaker != current.Speaker {
+			flush()
+		}
+		for _, word := range strings.Fields(seg.Text) {
+			if len(words) == 0 {
+				current = minuteItem{Start: seg.Start, Speaker: seg.Speaker}
+			}
+			words = append(words, word)
+			if endsSentence(word) {
+				flush()
+			}
+		}
+	}
+	flush()
+	return sentences
+}
+
+// keySentences returns the n sentences that use the meeting's most frequent
+// content words the most, in the order they were said
+func keySentences(sentences []minuteItem, counts map[string]int, n int) []minuteItem {
+	type scored struct {
+		index int
+		score float64
+	}
+	var candidates []scored
+	for i, s := range sentences {
+		words := len(strings.Fields(s.Text))
+		if words < minKeySentence {
+			continue
+		}
+		score := 0.0
+		for word := range keywordCounts(s.Text) {
+			score += float64(counts[word])
+		}
+		// Long sentences shouldn't win on length alone
+		candidates = append(candidates, scored{i, score / math.Sqrt(float64(words))})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].index < candidates[j].index })
+
+	items := make([]minuteItem, len(candidates))
+	for i, c := range candidates {
+		items[i] = sentences[c.index]
+	}
+	return items
+}
+
+// buildMinutes extracts the summary, decisions, action items and each
+// speaker's highlights from a transcript, using rules rather than a model
+func buildMinutes(t *Transcript) meetingMinutes {
+	minutes := meetingMinutes{Title: "Meeting", Duration: t.Duration}
+	if t.Source != "" {
+		minutes.Title = strings.TrimSuffix(filepath.Base(t.Source), filepath.Ext(t.Source))
+	}
+	if minutes.Duration == 0 {
+		minutes.Duration = t.duration()
+	}
+
+	sentences := transcriptSentences(t)
+	counts := keywordCounts(t.Text)
+	minutes.Summary = keySentences(sentences, counts, summarySentences)
+
+	for _, s := range sentences {
+		switch {
+		case decisionPattern.MatchString(s.Text):
+			minutes.Decisions = append(minutes.Decisions, s)
+		case actionPattern.MatchString(s.Text):
+			minutes.Actions = append(minutes.Actions, s)
+		}
+	}
+
+	for _, speaker := range t.speakers() {
+		a := attendee{Name: speaker}
+		for _, seg := range t.Segments {
+			if seg.Speaker == speaker {
+				a.Seconds += seg.End - seg.Start
+			}
+		}
+		var own []minuteItem
+		for _, s := range sentences {
+			if s.Speaker == speaker {
+				own = append(own, s)
+			}
+		}
+		a.Highlights = keySentences(own, counts, speakerHighlights)
+		minutes.Attendees = append(minutes.Attendees, a)
+	}
+	return minutes
+}
+
+// minuteLine renders a quoted sentence with its time and speaker
+func minuteLine(item minuteItem, stamps TimestampConfig) string {
+	if item.Speaker == "" {
+		return fmt.Sprintf("[%s] %s", stamps.format(item.Start), item.Text)
+	}
+	return fmt.Sprintf("[%s] %s: %s", stamps.format(item.Start), item.Speaker, item.Text)
+}
+
+// formatMinutes renders meeting minutes as Markdown
+func formatMinutes(minutes meetingMinutes, stamps TimestampConfig) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Minutes: %s\n\n", minutes.Title)
+	fmt.Fprintf(&b, "- Duration: %s\n", formatDuration(minutes.Duration))
+	if len(minutes.Attendees) > 0 {
+		var spoken float64
+		for _, a := range minutes.Attendees {
+			spoken += a.Seconds
+		}
+		var names []string
+		for _, a := range minutes.Attendees {
+			names = append(names, fmt.Sprintf("%s (%s, %.0f%%)", a.Name, formatDuration(a.Seconds), a.Seconds/spoken*100))
+		}
+		fmt.Fprintf(&b, "- Attendees: %s\n", strings.Join(names, ", "))
+	}
+
+	b.WriteString("\n## Summary\n\n")
+	if len(minutes.Summary) == 0 {
+		b.WriteString("Nothing to summarize.\n")
+	}
+	for _, item := range minutes.Summary {
+		fmt.Fprintf(&b, "- %s\n", minuteLine(item, stamps))
+	}
+
+	b.WriteString("\n## Decisions\n\n")
+	if len(minutes.Decisions) == 0 {
+		b.WriteString("None recorded.\n")
+	}
+	for _, item := range minutes.Decisions {
+		fmt.Fprintf(&b, "- %s\n", minuteLine(item, stamps))
+	}
+
+	b.WriteString("\n## Action Items\n\n")
+	if len(minutes.Actions) == 0 {
+		b.WriteString("None recorded.\n")
+	}
+	for _, item := range minutes.Actions {
+		fmt.Fprintf(&b, "- [ ] %s\n", minuteLine(item, stamps))
+	}
+
+	if len(minutes.Attendees) > 0 {
+		b.WriteString("\n## Highlights by Speaker\n")
+		for _, a := range minutes.Attendees {
+			fmt.Fprintf(&b, "\n### %s\n\n", a.Name)
+			if len(a.Highlights) == 0 {
+				b.WriteString("No long remarks.\n")
+			}
+			for _, item := range a.Highlights {
+				fmt.Fprintf(&b, "- [%s] %s\n", stamps.format(item.Start), item.Text)
+			}
+		}
+	}
+	return b.String()
+}
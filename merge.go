@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TranscriptPart records where one recording starts in a merged transcript
+type TranscriptPart struct {
+	Source string  `json:"source"`
+	Offset float64 `json:"offset"` // seconds from the start of the first part
+}
+
+// mergedExtension is added to the first input's name for merged transcripts
+const mergedExtension = ".merged"
+
+// mergeTranscripts joins the transcripts of consecutive recordings into one
+// with continuous timestamps: each part's times are offset by the length
+// of the parts before it. Segments in another language than the first
+// part's are tagged with their part's language. Diarization labels each
+// file's speakers from SPEAKER_00 again, so speakers are renumbered in
+// order of appearance with separate labels per part: the same person in
+// two files gets two labels rather than two people one.
+func mergeTranscripts(parts []*Transcript, cfg Config) *Transcript {
+	merged := &Transcript{}
+	if len(parts) == 0 {
+		return merged
+	}
+	merged.Language = parts[0].Language
+	merged.Model = parts[0].Model
+	merged.Backend = parts[0].Backend
+
+	var sources, texts []string
+	offset, speakerCount := 0.0, 0
+	for _, part := range parts {
+		speakers := map[string]string{}
+		sources = append(sources, part.Source)
+		merged.Parts = append(merged.Parts, TranscriptPart{Source: part.Source, Offset: offset})
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+
+		for _, seg := range part.Segments {
+			seg.Start += offset
+			seg.End += offset
+			seg.Words = append([]Word(nil), seg.Words...)
+			for i := range seg.Words {
+				seg.Words[i].Start += offset
+				seg.Words[i].End += offset
+			}
+			if seg.Language == "" && part.Language != merged.Language {
+				seg.Language = part.Language
+			}
+			if seg.Speaker != "" {
+				label, ok := speakers[seg.Speaker]
+				if !ok {
+					label = fmt.Sprintf("SPEAKER_%02d", speakerCount)
+					speakers[seg.Speaker] = label
+					speakerCount++
+				}
+				seg.Speaker = label
+			}
+			merged.Segments = append(merged.Segments, seg)
+		}
+
+		// Without ffprobe, the end of the last segment is the best estimate
+		length := part.Duration
+		if length <= 0 {
+			length = part.duration()
+		}
+		offset += length
+	}
+
+	merged.Source = strings.Join(sources, " + ")
+	merged.Text = strings.Join(texts, " ")
+	merged.Duration = offset
+	if cfg.Analyze {
+		analyzeTranscript(merged, cfg)
+	}
+	return merged
+}
+
+// mergedOutputs returns where the merged transcript of paths is written:
+// the --output, or files named after the first input
+func mergedOutputs(paths []string, opts batchOptions) []string {
+	if opts.Output != "" {
+		return []string{opts.Output}
+	}
+	return outputPaths(transcriptPath(paths[0], opts.OutputDir, mergedExtension), opts.Config)
+}
+
+// saveMerged merges the transcripts of a batch in the order the files were
+// given and saves the result
+func saveMerged(paths []string, results []batchFile, opts batchOptions) error {
+	parts := make([]*Transcript, len(results))
+	for i, file := range results {
+		if file.Result == nil {
+			return fmt.Errorf("%s has no transcript to merge", file.Path)
+		}
+		parts[i] = file.Result
+	}
+	merged := mergeTranscripts(parts, opts.Config)
+
+	saved, err := opts.save(merged, transcriptPath(paths[0], opts.OutputDir, mergedExtension), opts.Config)
+	status := opts.statusOutput()
+	for _, path := range saved {
+		fmt.Fprintf(status, "Merged %d files into %s\n", len(parts), path)
+	}
+	for _, part := range merged.Parts {
+		fmt.Fprintf(status, "  %s  %s\n", formatClock(part.Offset), part.Source)
+	}
+	return err
+}
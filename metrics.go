@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics exposed on /metrics by the server
+var (
+	jobsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "stt_jobs_total",
+		Help: "Transcription jobs finished, by result (success or failure).",
+	}, []string{"result"})
+
+	jobFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "stt_job_failures_total",
+		Help: "Failed transcription jobs by the pipeline stage that failed.",
+	}, []string{"stage"})
+
+	jobDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "stt_job_duration_seconds",
+		Help:    "Wall-clock time of transcription jobs, from start to finish.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 14),
+	})
+
+	transcriptionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "stt_transcription_duration_seconds",
+		Help:    "Time spent in the transcription backend per job.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 14),
+	})
+
+	realTimeFactor = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "stt_real_time_factor",
+		Help:    "Transcription time divided by audio duration; below 1 is faster than real time.",
+		Buckets: []float64{0.05, 0.1, 0.2, 0.3, 0.5, 0.75, 1, 1.5, 2, 3, 5},
+	})
+
+	audioDuration = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "stt_audio_seconds_total",
+		Help: "Seconds of audio transcribed successfully.",
+	})
+
+	queueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "stt_queue_depth",
+		Help: "Jobs waiting to start.",
+	})
+
+	jobsRunning = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "stt_jobs_running",
+		Help: "Jobs currently being processed.",
+	})
+)
+
+var registerMetricsOnce sync.Once
+
+// registerMetrics adds the metrics to the default registry. Only server
+// modes call it, so the other commands don't pay for them.
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		prometheus.MustRegister(jobsTotal, jobFailures, jobDuration, transcriptionDuration,
+			realTimeFactor, audioDuration, queueDepth, jobsRunning)
+	})
+}
+
+// observeJob records the outcome of a finished job
+func observeJob(started time.Time, stats RunStats, err error) {
+	jobDuration.Observe(time.Since(started).Seconds())
+
+	if err != nil {
+		jobsTotal.WithLabelValues("failure").Inc()
+		jobFailures.WithLabelValues(failureStage(err)).Inc()
+		return
+	}
+
+	jobsTotal.WithLabelValues("success").Inc()
+	transcriptionDuration.Observe(stats.Transcribing.Seconds())
+	audioDuration.Add(stats.AudioDuration)
+	if stats.AudioDuration > 0 {
+		realTimeFactor.Observe(stats.Transcribing.Seconds() / stats.AudioDuration)
+	}
+}
+
+// failureStage names the pipeline stage an error came from
+func failureStage(err error) string {
+	var stageErr *StageError
+	if errors.As(err, &stageErr) {
+		return stageErr.Stage
+	}
+	return "other"
+}
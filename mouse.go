@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// resultButtons are the clickable actions on the results screen
+var resultButtons = []string{"Save", "Copy", "New file"}
+
+const buttonGap = "   "
+
+// renderButton draws a single clickable label
+func renderButton(label string) string {
+	return buttonStyle.Render("[ " + tr(label) + " ]")
+}
+
+// buttonsView renders the row of clickable actions
+func (m model) buttonsView() string {
+	rendered := make([]string, len(resultButtons))
+	for i, label := range resultButtons {
+		rendered[i] = renderButton(label)
+	}
+	return strings.Join(rendered, buttonGap)
+}
+
+// buttonAt returns the label of the button at screen cell x, y, or an empty
+// string. It mirrors how lipgloss.Place centers the content: the block is
+// centered as a whole and its lines are left-aligned within it.
+func (m model) buttonAt(x, y int) string {
+	content := m.content()
+	width, height := lipgloss.Width(content), lipgloss.Height(content)
+	left := int(math.Round(float64(m.width-width) * 0.5))
+	top := int(math.Round(float64(m.height-height) * 0.5))
+
+	// The buttons are always the last line of the results screen
+	if y != top+height-1 {
+		return ""
+	}
+
+	column := x - max(0, left)
+	position := 0
+	for _, label := range resultButtons {
+		buttonWidth := lipgloss.Width(renderButton(label))
+		if column >= position && column < position+buttonWidth {
+			return label
+		}
+		position += buttonWidth + len(buttonGap)
+	}
+	return ""
+}
+
+// handleMouse scrolls the transcript with the wheel and handles button clicks
+func (m model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	const wheelStep = 3
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.scrollOffset = max(0, m.scrollOffset-wheelStep)
+
+	case msg.Button == tea.MouseButtonWheelDown:
+		m.scrollOffset = min(m.maxScroll, m.scrollOffset+wheelStep)
+
+	case msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress:
+		if m.error != "" || m.transcript == nil {
+			return m, nil
+		}
+		switch m.buttonAt(msg.X, msg.Y) {
+		case "Save":
+			return m.openExport(), nil
+		case "Copy":
+			m.status = m.copyTranscript()
+		case "New file":
+			return m.newFile()
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// partialSuffix is added to the names of files saved from a partial
+// transcript, so they can't be mistaken for complete ones
+const partialSuffix = ".partial"
+
+// partialNote describes how much of the audio a partial transcript covers
+func partialNote(t *Transcript) string {
+	return fmt.Sprintf("Partial transcript: stopped at %s", formatClock(t.duration()))
+}
+
+// partialTranscript builds a transcript from the segments finished before
+// a run was stopped, formatted and corrected like a complete one. Steps
+// that need the whole audio, such as speakers and analysis, are skipped.
+func partialTranscript(segments []Segment, inputPath string, cfg Config) *Transcript {
+	t := &Transcript{
+		Segments: append([]Segment(nil), segments...),
+		Source:   inputPath,
+		Model:    cfg.Model,
+		Backend:  cfg.Backend,
+		Partial:  true,
+	}
+	if cfg.Language != "auto" {
+		t.Language = cfg.Language
+	}
+	texts := make([]string, len(t.Segments))
+	for i, seg := range t.Segments {
+		texts[i] = strings.TrimSpace(seg.Text)
+	}
+	t.Text = strings.Join(texts, " ")
+
+	applyTextFormatting(t, cfg)
+	if cfg.Corrections != "" {
+		// The run already failed once; bad rules shouldn't lose the text too
+		if rules, err := loadCorrections(cfg.Corrections); err == nil {
+			applyCorrections(t, rules)
+		}
+	}
+	t.Duration = t.duration()
+	return t
+}
+
+// processingRun is the transcription in progress on the processing screen.
+// The model holds it by pointer, like the audio player, since the model is
+// copied on every update while the pipeline keeps adding segments.
+type processingRun struct {
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	segments []Segment
+	stopping bool
+}
+
+// start begins a new run, dropping the segments of the last one
+func (r *processingRun) start() context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel, r.segments, r.stopping = cancel, nil, false
+	return ctx
+}
+
+// add records a segment the backend finished
+func (r *processingRun) add(seg Segment) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.segments = append(r.segments, seg)
+}
+
+// stop cancels the run; the segments finished so far are kept
+func (r *processingRun) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		r.cancel()
+		r.stopping = true
+	}
+}
+
+// finish releases the run's context once the pipeline has returned
+func (r *processingRun) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+}
+
+// stopped reports whether the user stopped the run
+func (r *processingRun) stopped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stopping
+}
+
+// finished returns a copy of the segments finished so far
+func (r *processingRun) finished() []Segment {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Segment(nil), r.segments...)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newPathInput creates the text field used to type or drop a file path
+func newPathInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = tr("Path: ")
+	ti.Placeholder = tr("drop a file here or press Tab to type a path")
+	ti.CharLimit = 4096
+	ti.Width = 60
+	return ti
+}
+
+// isDroppedPathStart reports whether a key press looks like the start of a
+// path pasted by the terminal when a file is dragged onto it
+func isDroppedPathStart(msg tea.KeyMsg) bool {
+	if msg.Paste {
+		return true
+	}
+	if msg.Type != tea.KeyRunes || len(msg.Runes) == 0 {
+		return false
+	}
+	switch msg.Runes[0] {
+	case '/', '\\', '~', '\'', '"':
+		return true
+	}
+	return false
+}
+
+// cleanDroppedPath normalizes a path as terminals paste it on drag-and-drop:
+// surrounding quotes, backslash-escaped spaces, file:// URIs and ~ are handled
+func cleanDroppedPath(raw string) string {
+	path := strings.TrimSpace(raw)
+
+	if len(path) >= 2 {
+		first, last := path[0], path[len(path)-1]
+		if (first == '\'' || first == '"') && first == last {
+			path = path[1 : len(path)-1]
+		}
+	}
+
+	if strings.HasPrefix(path, "file://") {
+		if u, err := url.Parse(path); err == nil {
+			path = u.Path
+			if runtime.GOOS == "windows" {
+				path = strings.TrimPrefix(path, "/")
+			}
+		}
+	}
+
+	// Unix terminals escape spaces and shell metacharacters with backslashes
+	if runtime.GOOS != "windows" && strings.Contains(path, "\\") {
+		var b strings.Builder
+		escaped := false
+		for _, r := range path {
+			if r == '\\' && !escaped {
+				escaped = true
+				continue
+			}
+			escaped = false
+			b.WriteRune(r)
+		}
+		path = b.String()
+	}
+
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+
+	return path
+}
+
+// updatePathInput handles key presses while the path field has focus
+func (m model) updatePathInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.ForceQuit):
+		return m, tea.Quit
+	case key.Matches(msg, keys.LeavePath):
+		m.pathInput.Blur()
+		m.inputError = ""
+		return m, nil
+	case msg.String() == "enter":
+		path := cleanDroppedPath(m.pathInput.Value())
+		if path == "" {
+			return m, nil
+		}
+
+		// URLs are downloaded by the processing pipeline
+		if isRemoteInput(path) {
+			m.pathInput.Reset()
+			m.pathInput.Blur()
+			m.inputError = ""
+			m.selectedFile = path
+			m.state = StateProcessing
+			return m, tea.Batch(m.spinner.Tick, m.startProcessing())
+		}
+
+		stat, err := os.Stat(path)
+		if err != nil {
+			m.inputError = tr("Cannot open %s", path)
+			return m, nil
+		}
+
+		// Dropping a folder navigates the picker there instead
+		if stat.IsDir() {
+			m.browser = newFileBrowser(path, m.height)
+			m.browser.Width = m.width
+			m.pathInput.Reset()
+			m.pathInput.Blur()
+			m.inputError = ""
+			return m, m.browser.Init()
+		}
+
+		m.pathInput.Reset()
+		m.pathInput.Blur()
+		m.inputError = ""
+		m.selectedFile = path
+		m.state = StateProcessing
+		return m, tea.Batch(m.spinner.Tick, m.startProcessing())
+	}
+
+	var cmd tea.Cmd
+	m.pathInput, cmd = m.pathInput.Update(msg)
+	m.inputError = ""
+	return m, cmd
+}
+
+// readPathList reads newline-separated paths, skipping blank lines
+func readPathList(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		path := cleanDroppedPath(scanner.Text())
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read path list: %w", err)
+	}
+	return paths, nil
+}
@@ -0,0 +1,8 @@
+package main
+
+import "os"
+
+// peakMemory is not available from the process state on Windows
+func peakMemory(state *os.ProcessState) uint64 {
+	return 0
+}
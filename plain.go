@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// isDumbTerminal reports whether the terminal cannot handle the full TUI
+func isDumbTerminal() bool {
+	return os.Getenv("TERM") == "dumb"
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// runPlain is the accessible alternative to the TUI: no alternate screen,
+// borders or spinners, just prompts and one status line per stage
+func runPlain(cfg Config) error {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Print(tr("Enter the path of a video or audio file (empty line to quit): "))
+		line, err := reader.ReadString('\n')
+		path := cleanDroppedPath(line)
+		if path == "" {
+			return nil
+		}
+
+		transcribePlain(reader, path, cfg)
+
+		// Stop at end of input, e.g. when stdin is a file
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// transcribePlain transcribes one file, printing each stage and then the
+// transcript, and saves it next to the file
+func transcribePlain(reader *bufio.Reader, path string, cfg Config) {
+	fmt.Println(tr("Processing %s", path))
+	transcript, err := processAudioSTTWithProgress(path, cfg, func(stage string) {
+		fmt.Println(tr("Status: %s", stage))
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	fmt.Println(tr("Transcription completed"))
+	fmt.Println()
+	if len(transcript.speakers()) > 0 {
+		transcript.renameSpeakers(promptSpeakerNames(reader, transcript))
+		fmt.Println()
+	}
+	if transcript.Text == "" {
+		fmt.Println(tr("No speech detected in the audio file."))
+	} else if len(transcript.speakers()) > 0 {
+		fmt.Println(transcript.speakerText())
+	} else {
+		fmt.Println(transcript.Text)
+	}
+	fmt.Println()
+
+	saved, err := writeTranscripts(transcript, transcriptPath(path, "", ""), cfg)
+	for _, outputPath := range saved {
+		fmt.Println(tr("Saved %s", outputPath))
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+}
+
+// promptSpeakerNames shows a few lines of each diarized speaker and asks
+// for their name; an empty answer keeps the label
+func promptSpeakerNames(reader *bufio.Reader, t *Transcript) map[string]string {
+	names := map[string]string{}
+	for _, speaker := range t.speakers() {
+		fmt.Println(tr("%s said:", speaker))
+		for _, sample := range t.speakerSamples(speaker, 2) {
+			fmt.Printf("  [%s] %s\n", formatClock(sample.Start), strings.TrimSpace(sample.Text))
+		}
+		fmt.Print(tr("Name for %s (empty to keep): ", speaker))
+		line, _ := reader.ReadString('\n')
+		names[speaker] = strings.TrimSpace(line)
+	}
+	return names
+}
+
+// promptEpisodes asks for episode numbers on stdin, accepting lists and
+// ranges such as "1,3-5" or "all"
+func promptEpisodes(episodes []Episode) ([]Episode, error) {
+	for i, episode := range episodes {
+		line := fmt.Sprintf("%3d. %s", i+1, episode.Title)
+		if episode.Duration != "" {
+			line += " (" + episode.Duration + ")"
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Print("Episodes to transcribe (e.g. 1,3-5 or all; empty to cancel): ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, nil
+	}
+	if strings.EqualFold(line, "all") {
+		return episodes, nil
+	}
+
+	var chosen []Episode
+	for _, part := range strings.Split(line, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+
+		from, err := strconv.Atoi(strings.TrimSpace(first))
+		if err != nil {
+			return nil, fmt.Errorf("invalid episode number %q", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(strings.TrimSpace(last)); err != nil {
+				return nil, fmt.Errorf("invalid episode range %q", part)
+			}
+		}
+		if from < 1 || to > len(episodes) || from > to {
+			return nil, fmt.Errorf("episode selection %q is out of range", part)
+		}
+
+		for i := from; i <= to; i++ {
+			chosen = append(chosen, episodes[i-1])
+		}
+	}
+	return chosen, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const playbackTickInterval = 250 * time.Millisecond
+
+// audioPlayer plays the source file with ffplay. ffplay can't be paused
+// without its window, so pausing stops the process and resuming restarts it
+// at the remembered position.
+type audioPlayer struct {
+	cmd        *exec.Cmd
+	offset     float64 // position playback started from, in seconds
+	started    time.Time
+	generation int
+}
+
+// playbackTickMsg drives highlighting while audio is playing
+type playbackTickMsg struct {
+	generation int
+}
+
+// playbackEndedMsg is sent when ffplay exits
+type playbackEndedMsg struct {
+	generation int
+	err        error
+}
+
+// Playing reports whether audio is currently playing
+func (p *audioPlayer) Playing() bool {
+	return p.cmd != nil
+}
+
+// Position returns the current playback position in seconds
+func (p *audioPlayer) Position() float64 {
+	if p.cmd == nil {
+		return p.offset
+	}
+	return p.offset + time.Since(p.started).Seconds()
+}
+
+// Play starts playback of input at the given position, replacing any
+// playback in progress
+func (p *audioPlayer) Play(input string, at float64) (tea.Cmd, error) {
+	return p.PlayFor(input, at, 0)
+}
+
+// PlayFor plays length seconds of input from the given position, or up to
+// the end when length is 0
+func (p *audioPlayer) PlayFor(input string, at, length float64) (tea.Cmd, error) {
+	p.Stop()
+
+	ffmpegPath, _ := findFFmpeg()
+	ffplayPath := findFFmpegTool("ffplay", ffmpegPath)
+	if ffplayPath == "" {
+		return nil, fmt.Errorf("ffplay not found (it ships with FFmpeg)")
+	}
+
+	args := []string{
+		"-nodisp",
+		"-autoexit",
+		"-loglevel", "quiet",
+		"-ss", fmt.Sprintf("%.3f", at),
+	}
+	if length > 0 {
+		args = append(args, "-t", fmt.Sprintf("%.3f", length))
+	}
+	cmd := exec.Command(ffplayPath, append(args, input)...)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffplay: %w", err)
+	}
+
+	p.generation++
+	p.cmd = cmd
+	p.offset = at
+	p.started = time.Now()
+
+	generation := p.generation
+	wait := func() tea.Msg {
+		return playbackEndedMsg{generation: generation, err: cmd.Wait()}
+	}
+	return tea.Batch(wait, playbackTick(generation)), nil
+}
+
+// Stop ends playback, remembering the position for resuming
+func (p *audioPlayer) Stop() {
+	if p.cmd == nil {
+		return
+	}
+	p.offset = p.Position()
+	p.cmd.Process.Kill()
+	p.cmd = nil
+	p.generation++
+}
+
+// Reset stops playback and rewinds to the beginning
+func (p *audioPlayer) Reset() {
+	p.Stop()
+	p.offset = 0
+}
+
+// playbackTick schedules the next highlight update
+func playbackTick(generation int) tea.Cmd {
+	return tea.Tick(playbackTickInterval, func(time.Time) tea.Msg {
+		return playbackTickMsg{generation: generation}
+	})
+}
+
+// segmentAt returns the index of the segment being spoken at the given
+// time, or -1 if none
+func segmentAt(segments []Segment, position float64) int {
+	for i, seg := range segments {
+		if position >= seg.Start && position < seg.End {
+			return i
+		}
+	}
+	return -1
+}
+
+// canPlay reports whether the selected input can be played back
+func (m model) canPlay() bool {
+	if m.transcript == nil || len(m.transcript.Segments) == 0 {
+		return false
+	}
+	// Downloaded copies of s3:// and sftp:// inputs are gone by now
+	return !isRemoteInput(m.selectedFile) || isHTTPInput(m.selectedFile)
+}
+
+// togglePlayback pauses or resumes playback of the source audio
+func (m model) togglePlayback() (tea.Model, tea.Cmd) {
+	if !m.canPlay() {
+		m.status = tr("Playback is not available for this file")
+		return m, nil
+	}
+	if m.player.Playing() {
+		m.player.Stop()
+		return m, nil
+	}
+	return m.playFrom(m.player.Position())
+}
+
+// playFrom starts playback at the given position
+func (m model) playFrom(position float64) (tea.Model, tea.Cmd) {
+	cmd, err := m.player.Play(m.selectedFile, position)
+	if err != nil {
+		m.status = err.Error()
+		return m, nil
+	}
+	m.playingSegment = segmentAt(m.transcript.Segments, position)
+	return m, cmd
+}
+
+// seekToSelectedSegment starts playback at the selected segment
+func (m model) seekToSelectedSegment() (tea.Model, tea.Cmd) {
+	if !m.canPlay() {
+		m.status = tr("Playback is not available for this file")
+		return m, nil
+	}
+	return m.playFrom(m.transcript.Segments[m.selectedSegment].Start)
+}
+
+// updatePlayback follows playback with the highlight, scrolling the
+// spoken segment into view
+func (m model) updatePlayback() model {
+	m.playingSegment = segmentAt(m.transcript.Segments, m.player.Position())
+	if m.playingSegment < 0 {
+		return m
+	}
+
+	lines := m.transcriptLines()
+	for i, line := range lines {
+		if line.segment != m.playingSegment {
+			continue
+		}
+		if i < m.scrollOffset || i >= m.scrollOffset+m.transcriptionHeight() {
+			m.scrollOffset = min(i, m.maxScroll)
+		}
+		break
+	}
+	return m
+}
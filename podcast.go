@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Episode is a single podcast episode with downloadable audio
+type Episode struct {
+	Title     string
+	Published string
+	Duration  string
+	AudioURL  string
+}
+
+// rssFeed mirrors the parts of an RSS 2.0 podcast feed we need
+type rssFeed struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title     string `xml:"title"`
+			PubDate   string `xml:"pubDate"`
+			Duration  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+			Enclosure struct {
+				URL  string `xml:"url,attr"`
+				Type string `xml:"type,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// fetchPodcastFeed downloads and parses a podcast RSS feed, returning the
+// feed title and the episodes that have an audio enclosure
+func fetchPodcastFeed(feedURL string) (string, []Episode, error) {
+	resp, err := http.Get(feedURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("failed to fetch feed: %s", resp.Status)
+	}
+
+	var feed rssFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return "", nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	var episodes []Episode
+	for _, item := range feed.Channel.Items {
+		// Anything but a web URL would be read as a local path, an S3
+		// object or an SSH host, none of which a feed should choose
+		if !isHTTPInput(item.Enclosure.URL) {
+			continue
+		}
+		episodes = append(episodes, Episode{
+			Title:     strings.TrimSpace(item.Title),
+			Published: strings.TrimSpace(item.PubDate),
+			Duration:  strings.TrimSpace(item.Duration),
+			AudioURL:  item.Enclosure.URL,
+		})
+	}
+
+	return strings.TrimSpace(feed.Channel.Title), episodes, nil
+}
+
+// sanitizeFilename turns an episode title into a safe file name
+func sanitizeFilename(name string) string {
+	replacer := strings.NewReplacer(
+		"/", "-", "\\", "-", ":", " -", "*", "", "?", "",
+		"\"", "'", "<", "", ">", "", "|", "-",
+	)
+	name = strings.TrimSpace(replacer.Replace(name))
+	name = strings.Trim(name, ". ")
+	if runes := []rune(name); len(runes) > 120 {
+		name = strings.TrimSpace(string(runes[:120]))
+	}
+	if name == "" {
+		return "episode"
+	}
+	return name
+}
+
+// podcastModel is the episode selection screen
+type podcastModel struct {
+	feedTitle string
+	episodes  []Episode
+	selected  map[int]bool
+	cursor    int
+	offset    int
+	width     int
+	height    int
+	confirmed bool
+}
+
+func (m podcastModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m podcastModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.episodes)-1 {
+				m.cursor++
+			}
+		case " ", "x":
+			m.selected[m.cursor] = !m.selected[m.cursor]
+		case "a":
+			// Toggle all episodes
+			all := len(m.selectedEpisodes()) < len(m.episodes)
+			for i := range m.episodes {
+				m.selected[i] = all
+			}
+		case "enter":
+			if len(m.selectedEpisodes()) == 0 {
+				m.selected[m.cursor] = true
+			}
+			m.confirmed = true
+			return m, tea.Quit
+		}
+
+		// Keep the cursor inside the visible window
+		visible := m.visibleRows()
+		if m.cursor < m.offset {
+			m.offset = m.cursor
+		} else if m.cursor >= m.offset+visible {
+			m.offset = m.cursor - visible + 1
+		}
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	}
+
+	return m, nil
+}
+
+// visibleRows returns how many episodes fit on screen
+func (m podcastModel) visibleRows() int {
+	return max(3, m.height-8)
+}
+
+// selectedEpisodes returns the chosen episodes in feed order
+func (m podcastModel) selectedEpisodes() []Episode {
+	var episodes []Episode
+	for i, episode := range m.episodes {
+		if m.selected[i] {
+			episodes = append(episodes, episode)
+		}
+	}
+	return episodes
+}
+
+func (m podcastModel) View() string {
+	var rows []string
+	end := min(m.offset+m.visibleRows(), len(m.episodes))
+	for i := m.offset; i < end; i++ {
+		episode := m.episodes[i]
+
+		check := "[ ]"
+		if m.selected[i] {
+			check = "[x]"
+		}
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+
+		row := fmt.Sprintf("%s%s %s", cursor, check, episode.Title)
+		if episode.Duration != "" {
+			row += subtitleStyle.Render(" (" + episode.Duration + ")")
+		}
+		if i == m.cursor {
+			row = successStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	content := fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s",
+		titleStyle.Render("Speech-to-Text CLI"),
+		subtitleStyle.Render(fmt.Sprintf("%s • %d episodes", m.feedTitle, len(m.episodes))),
+		strings.Join(rows, "\n"),
+		subtitleStyle.Render(fmt.Sprintf("Space to select • A to toggle all • Enter to transcribe %d selected • Q to cancel", len(m.selectedEpisodes()))))
+
+	return content
+}
+
+// runPodcast lets the user pick episodes from a feed and transcribes them
+func runPodcast(args []string) error {
+	fs := flag.NewFlagSet("podcast", flag.ContinueOnError)
+	outputDir := fs.String("output-dir", ".", "directory to write episode transcripts to")
+	plain := fs.Bool("plain", false, "choose episodes by number instead of the interactive list")
+	cfg, err := addConfigFlags(fs)
+	if err != nil {
+		return err
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: stt-cli podcast [--output-dir DIR] FEED_URL")
+	}
+
+	feedTitle, episodes, err := fetchPodcastFeed(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(episodes) == 0 {
+		return fmt.Errorf("feed has no episodes with audio")
+	}
+
+	var chosen []Episode
+	if *plain || isDumbTerminal() {
+		fmt.Printf("%s: %d episodes\n", feedTitle, len(episodes))
+		if chosen, err = promptEpisodes(episodes); err != nil {
+			return err
+		}
+	} else {
+		picker := podcastModel{
+			feedTitle: feedTitle,
+			episodes:  episodes,
+			selected:  make(map[int]bool),
+			width:     80,
+			height:    24,
+		}
+		result, err := tea.NewProgram(picker, tea.WithAltScreen()).Run()
+		if err != nil {
+			return err
+		}
+
+		picker = result.(podcastModel)
+		if picker.confirmed {
+			chosen = picker.selectedEpisodes()
+		}
+	}
+	if len(chosen) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	failed := 0
+	names := map[string]int{} // episodes per file name, so none overwrite another
+	for i, episode := range chosen {
+		fmt.Printf("[%d/%d] %s\n", i+1, len(chosen), episode.Title)
+
+		transcript, err := processAudioSTT(episode.AudioURL, *cfg)
+		if err != nil {
+			fmt.Printf("  failed: %v\n", err)
+			failed++
+			continue
+		}
+
+		name := sanitizeFilename(episode.Title)
+		key := strings.ToLower(name) // for case-insensitive file systems
+		if names[key]++; names[key] > 1 {
+			name = fmt.Sprintf("%s (%d)", name, names[key])
+		}
+		saved, err := writeTranscripts(transcript, filepath.Join(*outputDir, name), *cfg)
+		for _, outputPath := range saved {
+			fmt.Printf("  saved %s\n", outputPath)
+		}
+		if err != nil {
+			fmt.Printf("  %v\n", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d episodes failed", failed, len(chosen))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// builtinPresets are available without any configuration. Presets in the
+// config file with the same name replace them.
+var builtinPresets = map[string]string{
+	"quick":             `{"model": "tiny", "output_format": "txt"}`,
+	"meetings":          `{"model": "medium", "output_format": "minutes,json", "diarize": true, "capitalize": true, "denoise": true, "normalize": true}`,
+	"subtitles":         `{"model": "large-v3", "output_format": "srt,vtt"}`,
+	"english-subtitles": `{"model": "large-v3", "output_format": "srt", "translate": true}`,
+}
+
+// presets returns every preset by name, built-in ones included
+func (c Config) presets() map[string]json.RawMessage {
+	all := make(map[string]json.RawMessage, len(builtinPresets)+len(c.Presets))
+	for name, preset := range builtinPresets {
+		all[name] = json.RawMessage(preset)
+	}
+	for name, preset := range c.Presets {
+		all[name] = preset
+	}
+	return all
+}
+
+// presetNames returns the preset names in alphabetical order
+func (c Config) presetNames() []string {
+	var names []string
+	for name := range c.presets() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyPreset overlays the named preset on the config. Settings the preset
+// doesn't mention keep their values.
+func (c *Config) applyPreset(name string) error {
+	preset, ok := c.presets()[name]
+	if !ok {
+		return fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(c.presetNames(), ", "))
+	}
+
+	// Presets can't define or remove other presets
+	presets := c.Presets
+	err := json.Unmarshal(preset, c)
+	c.Presets = presets
+	if err != nil {
+		return fmt.Errorf("invalid preset %q: %w", name, err)
+	}
+	return nil
+}
+
+// presetSummary describes a preset on one line
+func presetSummary(preset json.RawMessage) string {
+	var fields map[string]any
+	if err := json.Unmarshal(preset, &fields); err != nil {
+		return "invalid preset"
+	}
+
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%v", name, fields[name])
+	}
+	return strings.Join(parts, " ")
+}
+
+// openPresets switches to the preset chooser, remembering where to return
+func (m model) openPresets() model {
+	m.prevState = m.state
+	m.state = StatePresets
+	m.settingsError = ""
+	return m
+}
+
+// updatePresets handles key presses on the preset chooser
+func (m model) updatePresets(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	names := m.config.presetNames()
+
+	switch {
+	case key.Matches(msg, keys.ForceQuit):
+		return m, tea.Quit
+	case key.Matches(msg, keys.Close):
+		m.state = m.prevState
+	case key.Matches(msg, keys.Up):
+		if m.presetCursor > 0 {
+			m.presetCursor--
+		}
+	case key.Matches(msg, keys.Down):
+		if m.presetCursor < len(names)-1 {
+			m.presetCursor++
+		}
+	case key.Matches(msg, keys.ApplyPreset):
+		cfg := m.config
+		if err := cfg.applyPreset(names[m.presetCursor]); err != nil {
+			m.settingsError = err.Error()
+			return m, nil
+		}
+		if err := cfg.validate(); err != nil {
+			m.settingsError = err.Error()
+			return m, nil
+		}
+
+		// Like the settings screen, the applied values are saved
+		m.config = cfg
+		if err := m.config.save(); err != nil {
+			m.settingsError = tr("Could not save settings: %s", err.Error())
+			return m, nil
+		}
+		m.state = m.prevState
+		m.status = tr("Applied preset %s", names[m.presetCursor])
+	}
+	return m, nil
+}
+
+// presetsView renders the preset chooser
+func (m model) presetsView() string {
+	presets := m.config.presets()
+
+	var rows []string
+	for i, name := range m.config.presetNames() {
+		row := fmt.Sprintf("  %-20s %s", name, subtitleStyle.Render(presetSummary(presets[name])))
+		if i == m.presetCursor {
+			row = successStyle.Render(fmt.Sprintf("> %-20s", name)) + " " + subtitleStyle.Render(presetSummary(presets[name]))
+		}
+		rows = append(rows, row)
+	}
+
+	status := subtitleStyle.Render(tr("↑/↓ to move • Enter to apply • Esc to go back"))
+	if m.settingsError != "" {
+		status = errorStyle.Render(m.settingsError)
+	}
+
+	return fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s",
+		titleStyle.Render("Speech-to-Text CLI"),
+		subtitleStyle.Render(tr("Presets")),
+		strings.Join(rows, "\n"),
+		status)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// recordingInput returns the ffmpeg input arguments for capturing from the
+// microphone or system audio on the current platform
+func recordingInput(source, device string) ([]string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		if device == "" {
+			device = "default"
+			if source == "system" {
+				device = "@DEFAULT_MONITOR@"
+			}
+		}
+		return []string{"-f", "pulse", "-i", device}, nil
+
+	case "darwin":
+		if device == "" {
+			if source == "system" {
+				return nil, fmt.Errorf("capturing system audio on macOS needs a loopback device such as BlackHole; pass it with --device")
+			}
+			device = "0"
+		}
+		return []string{"-f", "avfoundation", "-i", ":" + device}, nil
+
+	case "windows":
+		if device == "" {
+			if source == "system" {
+				device = "Stereo Mix"
+			} else {
+				return nil, fmt.Errorf("pass the microphone name with --device (see --list-devices)")
+			}
+		}
+		return []string{"-f", "dshow", "-i", "audio=" + device}, nil
+	}
+
+	return nil, fmt.Errorf("recording is not supported on %s", runtime.GOOS)
+}
+
+// listRecordingDevices prints the capture devices ffmpeg can see
+func listRecordingDevices(ffmpegPath string) error {
+	var args []string
+	switch runtime.GOOS {
+	case "linux":
+		fmt.Println("Use a PulseAudio source name with --device (see `pactl list short sources`).")
+		return nil
+	case "darwin":
+		args = []string{"-f", "avfoundation", "-list_devices", "true", "-i", ""}
+	case "windows":
+		args = []string{"-f", "dshow", "-list_devices", "true", "-i", "dummy"}
+	default:
+		return fmt.Errorf("recording is not supported on %s", runtime.GOOS)
+	}
+
+	// ffmpeg prints the device list to stderr and exits with an error
+	output, _ := exec.Command(ffmpegPath, args...).CombinedOutput()
+	fmt.Print(string(output))
+	return nil
+}
+
+// recordAudio captures audio to outputPath until the duration elapses, the
+// user presses Enter, or the process is interrupted
+func recordAudio(ffmpegPath string, input []string, duration time.Duration, outputPath string) error {
+	args := append([]string{"-hide_banner", "-loglevel", "error"}, input...)
+	if duration > 0 {
+		args = append(args, "-t", fmt.Sprintf("%.3f", duration.Seconds()))
+	}
+	args = append(args,
+		"-ar", "16000", // 16kHz sample rate for Whisper
+		"-ac", "1", // mono
+		outputPath,
+		"-y", // overwrite output file
+	)
+
+	cmd := exec.Command(ffmpegPath, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	// ffmpeg finishes the file cleanly when it reads "q" on stdin
+	stop := make(chan struct{}, 1)
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	go func() {
+		bufio.NewReader(os.Stdin).ReadString('\n')
+		stop <- struct{}{}
+	}()
+	go func() {
+		<-interrupts
+		stop <- struct{}{}
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err = <-done:
+	case <-stop:
+		io.WriteString(stdin, "q")
+		stdin.Close()
+		err = <-done
+	}
+
+	if _, statErr := os.Stat(outputPath); statErr != nil {
+		if err == nil {
+			err = statErr
+		}
+		return fmt.Errorf("recording failed: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// runRecord records audio, saves it to disk and transcribes it
+func runRecord(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ContinueOnError)
+	duration := fs.Duration("duration", 0, "how long to record (e.g. 30m); 0 records until Enter is pressed")
+	source := fs.String("source", "mic", "what to record: mic or system")
+	device := fs.String("device", "", "capture device name (platform specific)")
+	output := fs.String("output", "", "where to save the recording (default: recording-<timestamp>.wav)")
+	listDevices := fs.Bool("list-devices", false, "list capture devices and exit")
+	cfg, err := addConfigFlags(fs)
+	if err != nil {
+		return err
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	ffmpegPath, err := findFFmpeg()
+	if err != nil {
+		return err
+	}
+	if *listDevices {
+		return listRecordingDevices(ffmpegPath)
+	}
+
+	if *source != "mic" && *source != "system" {
+		return fmt.Errorf("--source must be mic or system")
+	}
+	input, err := recordingInput(*source, *device)
+	if err != nil {
+		return err
+	}
+
+	outputPath := *output
+	if outputPath == "" {
+		outputPath = fmt.Sprintf("recording-%s.wav", time.Now().Format("20060102-150405"))
+	}
+	if dir := filepath.Dir(outputPath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	if *duration > 0 {
+		fmt.Printf("Recording %s audio for %s... press Enter to stop early\n", *source, *duration)
+	} else {
+		fmt.Printf("Recording %s audio... press Enter to stop\n", *source)
+	}
+	if err := recordAudio(ffmpegPath, input, *duration, outputPath); err != nil {
+		return err
+	}
+	fmt.Printf("Saved recording to %s\n", outputPath)
+
+	fmt.Println("Transcribing...")
+	transcript, err := processAudioSTT(outputPath, *cfg)
+	if err != nil {
+		return err
+	}
+
+	saved, err := writeTranscripts(transcript, transcriptPath(outputPath, "", ""), *cfg)
+	for _, transcriptFile := range saved {
+		fmt.Printf("Saved transcript to %s\n", transcriptFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// isRemoteInput reports whether an input refers to a remote object rather
+// than a local file
+func isRemoteInput(input string) bool {
+	u, err := url.Parse(input)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "s3", "sftp", "http", "https":
+		return true
+	}
+	return false
+}
+
+// isHTTPInput reports whether an input is an http:// or https:// URL
+func isHTTPInput(input string) bool {
+	u, err := url.Parse(input)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// remoteBaseName returns the file name part of a remote input URL
+func remoteBaseName(input string) string {
+	u, err := url.Parse(input)
+	if err != nil || path.Base(u.Path) == "/" || path.Base(u.Path) == "." {
+		return "download"
+	}
+	return path.Base(u.Path)
+}
+
+// fetchRemoteInput downloads a remote input into dir and returns the local
+// path. Credentials come from the standard tool configuration: the AWS CLI
+// config for s3:// and the SSH config for sftp://. Inputs larger than
+// limit bytes are rejected with errTooLarge; 0 means no limit.
+func fetchRemoteInput(ctx context.Context, input, dir string, limit int64) (string, error) {
+	u, err := url.Parse(input)
+	if err != nil {
+		return "", fmt.Errorf("invalid input URL: %w", err)
+	}
+
+	localPath := filepath.Join(dir, "remote_"+remoteBaseName(input))
+
+	switch u.Scheme {
+	case "http", "https":
+		err = downloadHTTP(ctx, input, localPath, limit)
+	case "s3":
+		err = downloadS3(ctx, u, localPath)
+	case "sftp":
+		err = downloadSFTP(ctx, u, localPath)
+	default:
+		err = fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	// aws and scp can't stop at a size, so their copies are checked after
+	if err == nil && limit > 0 {
+		if info, statErr := os.Stat(localPath); statErr == nil && info.Size() > limit {
+			err = fmt.Errorf("%w: %s is larger than %s", errTooLarge, input, formatSize(limit))
+		}
+	}
+	if err != nil {
+		os.Remove(localPath)
+		return "", err
+	}
+
+	return localPath, nil
+}
+
+// downloadHTTP streams an HTTP(S) resource to a local file, failing once
+// it passes limit bytes when limit isn't 0
+func downloadHTTP(ctx context.Context, rawURL, localPath string, limit int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid input URL: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed: %s", resp.Status)
+	}
+	tooLarge := fmt.Errorf("%w: %s is larger than %s", errTooLarge, rawURL, formatSize(limit))
+	if limit > 0 && resp.ContentLength > limit {
+		return tooLarge
+	}
+	body := io.Reader(resp.Body)
+	if limit > 0 {
+		// One byte over the limit tells a full download from a cut one
+		body = io.LimitReader(resp.Body, limit+1)
+	}
+
+	out, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create download file: %w", err)
+	}
+	defer out.Close()
+
+	written, err := io.Copy(out, body)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	if limit > 0 && written > limit {
+		return tooLarge
+	}
+	return nil
+}
+
+// downloadS3 copies an object from S3 using the AWS CLI, which picks up
+// credentials, profiles and regions from the usual AWS config files
+func downloadS3(ctx context.Context, u *url.URL, localPath string) error {
+	awsPath, err := exec.LookPath("aws")
+	if err != nil {
+		return fmt.Errorf("aws CLI not found in PATH (required for s3:// inputs)")
+	}
+
+	cmd := exec.CommandContext(ctx, awsPath, "s3", "cp", "--only-show-errors", u.String(), localPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("s3 download error: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// downloadSFTP copies a file over SSH using scp, which reads host aliases,
+// users, keys and ports from the SSH config
+func downloadSFTP(ctx context.Context, u *url.URL, localPath string) error {
+	scpPath, err := exec.LookPath("scp")
+	if err != nil {
+		return fmt.Errorf("scp not found in PATH (required for sftp:// inputs)")
+	}
+
+	// scp would read a user or host starting with a dash as an option,
+	// such as -oProxyCommand, which runs a command
+	host := u.Hostname()
+	if host == "" || strings.HasPrefix(host, "-") {
+		return fmt.Errorf("invalid sftp host %q", host)
+	}
+	if u.User != nil && u.User.Username() != "" {
+		if strings.HasPrefix(u.User.Username(), "-") {
+			return fmt.Errorf("invalid sftp user %q", u.User.Username())
+		}
+		host = u.User.Username() + "@" + host
+	}
+
+	// sftp://host/~/file.mp4 refers to a path relative to the home directory
+	remotePath := u.Path
+	if strings.HasPrefix(remotePath, "/~/") {
+		remotePath = strings.TrimPrefix(remotePath, "/~/")
+	}
+
+	args := []string{"-q", "-o", "BatchMode=yes"}
+	if u.Port() != "" {
+		args = append(args, "-P", u.Port())
+	}
+	args = append(args, "--", host+":"+remotePath, localPath)
+
+	cmd := exec.CommandContext(ctx, scpPath, args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("sftp download error: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// jobPriority orders waiting jobs; higher runs first
+type jobPriority int
+
+const (
+	priorityLow jobPriority = iota
+	priorityNormal
+	priorityHigh
+)
+
+// parsePriority reads a priority name, defaulting to normal
+func parsePriority(name string) (jobPriority, error) {
+	switch name {
+	case "low":
+		return priorityLow, nil
+	case "", "normal":
+		return priorityNormal, nil
+	case "high":
+		return priorityHigh, nil
+	}
+	return priorityNormal, fmt.Errorf("unknown priority %q (use low, normal or high)", name)
+}
+
+// String returns the name parsePriority accepts
+func (p jobPriority) String() string {
+	switch p {
+	case priorityLow:
+		return "low"
+	case priorityHigh:
+		return "high"
+	}
+	return "normal"
+}
+
+// errDraining is returned for jobs submitted after shutdown has started
+var errDraining = errors.New("server is shutting down")
+
+// waitingJob is a job queued for a free slot
+type waitingJob struct {
+	priority jobPriority
+	seq      int // submission order, so equal priorities run first come first served
+	ready    chan struct{}
+	index    int
+}
+
+// jobQueue is a heap of waiting jobs, highest priority first
+type jobQueue []*waitingJob
+
+func (q jobQueue) Len() int { return len(q) }
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+func (q jobQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+func (q *jobQueue) Push(x any) {
+	job := x.(*waitingJob)
+	job.index = len(*q)
+	*q = append(*q, job)
+}
+func (q *jobQueue) Pop() any {
+	old := *q
+	job := old[len(old)-1]
+	*q = old[:len(old)-1]
+	job.index = -1
+	return job
+}
+
+// scheduler runs at most maxJobs jobs at once, starting waiting jobs by
+// priority, with an optional timeout per job
+type scheduler struct {
+	maxJobs int
+	timeout time.Duration
+
+	// base is cancelled to abort running jobs when draining takes too long
+	base   context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	queue    jobQueue
+	running  int
+	seq      int
+	draining bool
+	jobs     sync.WaitGroup
+}
+
+// newScheduler creates a scheduler. A zero timeout lets jobs run as long
+// as they need.
+func newScheduler(maxJobs int, timeout time.Duration) *scheduler {
+	base, cancel := context.WithCancel(context.Background())
+	return &scheduler{
+		maxJobs: max(1, maxJobs),
+		timeout: timeout,
+		base:    base,
+		cancel:  cancel,
+	}
+}
+
+// run waits for a free slot and runs fn. The context passed to fn is
+// cancelled when ctx is (e.g. the client disconnects), when the job times
+// out, or when a drain gives up waiting.
+func (s *scheduler) run(ctx context.Context, priority jobPriority, fn func(ctx context.Context) error) error {
+	s.mu.Lock()
+	if s.draining {
+		s.mu.Unlock()
+		return errDraining
+	}
+	s.jobs.Add(1)
+	defer s.jobs.Done()
+
+	if s.running < s.maxJobs && s.queue.Len() == 0 {
+		s.running++
+		s.mu.Unlock()
+	} else {
+		s.seq++
+		job := &waitingJob{priority: priority, seq: s.seq, ready: make(chan struct{})}
+		heap.Push(&s.queue, job)
+		queueDepth.Inc()
+		s.mu.Unlock()
+
+		select {
+		case <-job.ready:
+		case <-ctx.Done():
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if job.index >= 0 {
+				heap.Remove(&s.queue, job.index)
+				queueDepth.Dec()
+				return ctx.Err()
+			}
+			// The slot was handed over just as the caller gave up
+			s.releaseLocked()
+			return ctx.Err()
+		}
+	}
+	defer s.release()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.base.Done():
+			cancel()
+		case <-runCtx.Done():
+		}
+	}()
+
+	jobCtx := runCtx
+	if s.timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		jobCtx, cancelTimeout = context.WithTimeout(runCtx, s.timeout)
+		defer cancelTimeout()
+	}
+
+	jobsRunning.Inc()
+	defer jobsRunning.Dec()
+	return fn(jobCtx)
+}
+
+// release frees a slot, handing it to the next waiting job
+func (s *scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+func (s *scheduler) releaseLocked() {
+	if s.queue.Len() > 0 {
+		job := heap.Pop(&s.queue).(*waitingJob)
+		queueDepth.Dec()
+		close(job.ready)
+		return
+	}
+	s.running--
+}
+
+// pending returns the number of running and waiting jobs
+func (s *scheduler) pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running + s.queue.Len()
+}
+
+// drain stops accepting jobs and waits for the submitted ones to finish.
+// If ctx expires first, the remaining jobs are cancelled.
+func (s *scheduler) drain(ctx context.Context) error {
+	s.mu.Lock()
+	s.draining = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return fmt.Errorf("drain timed out; remaining jobs were cancelled")
+	}
+}
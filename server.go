@@ -0,0 +1,476 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+)
+
+// transcriptionServer runs transcription jobs for the HTTP and gRPC APIs
+type transcriptionServer struct {
+	config      Config
+	allowPaths  bool
+	scheduler   *scheduler
+	auth        *authenticator // nil when the APIs are open
+	maxUpload   int64          // bytes, 0 for no limit
+	maxDuration time.Duration  // 0 for no limit
+	jobs        *jobStore      // nil when job history is unavailable
+}
+
+// errTooLarge is returned for uploads and audio beyond the server's limits
+var errTooLarge = errors.New("limit exceeded")
+
+// checkDuration rejects audio longer than the server allows
+func (s *transcriptionServer) checkDuration(info *MediaInfo) error {
+	if info == nil || info.Duration <= 0 {
+		return fmt.Errorf("%w: cannot determine the audio duration (is ffprobe installed?)", errTooLarge)
+	}
+	duration := time.Duration(info.Duration * float64(time.Second))
+	if duration > s.maxDuration {
+		return fmt.Errorf("%w: audio is %s long, the maximum is %s", errTooLarge, duration.Round(time.Second), s.maxDuration)
+	}
+	return nil
+}
+
+// requestConfig applies a request's overrides to the server settings.
+// Clients may only pick the standard model sizes or the server's own
+// model: backends load any other name as a local checkpoint, which is
+// unpickled, or fetch it from Hugging Face.
+func (s *transcriptionServer) requestConfig(model, language, backend string, translate bool) (Config, error) {
+	cfg := s.config
+	if model != "" && model != cfg.Model {
+		known := false
+		for _, size := range modelSizes {
+			known = known || size == model
+		}
+		if !known {
+			return cfg, fmt.Errorf("unknown model %q (available: %s)", model, strings.Join(modelSizes, ", "))
+		}
+		cfg.Model = model
+	}
+	if language != "" {
+		cfg.Language = language
+	}
+	if backend != "" {
+		if _, err := findBackend(backend); err != nil {
+			return cfg, err
+		}
+		cfg.Backend = backend
+	}
+	cfg.Translate = cfg.Translate || translate
+	return cfg, cfg.validate()
+}
+
+// checkInput rejects server-side paths and URLs unless they were allowed
+func (s *transcriptionServer) checkInput(input string) error {
+	if input == "" {
+		return fmt.Errorf("no audio uploaded")
+	}
+	if !s.allowPaths {
+		return fmt.Errorf("server-side paths and URLs are disabled (start the server with --allow-paths)")
+	}
+	return nil
+}
+
+// transcribe records one job and queues it, running it when the scheduler
+// has a free slot. input is the job's input or the stored upload.
+func (s *transcriptionServer) transcribe(ctx context.Context, job *jobRecord, input string, hooks PipelineHooks) (*Transcript, error) {
+	if s.maxDuration > 0 {
+		hooks.Media = s.checkDuration
+	}
+	hooks.MaxDownload = s.maxUpload
+
+	job.Mode = "serve"
+	if err := s.jobs.create(job); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	var transcript *Transcript
+	var stats RunStats
+	err := s.scheduler.run(ctx, job.Priority, func(ctx context.Context) error {
+		s.jobs.start(job)
+		started := time.Now()
+		var err error
+		transcript, stats, err = runPipeline(ctx, input, job.Config, hooks)
+		observeJob(started, stats, err)
+		return err
+	})
+
+	var outputs []string
+	if err == nil {
+		path, saveErr := s.jobs.saveTranscript(job, transcript)
+		if saveErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: job %d: %v\n", job.ID, saveErr)
+		} else if path != "" {
+			outputs = append(outputs, path)
+		}
+	}
+	if finishErr := s.jobs.finish(job, stats, outputs, err); finishErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: job %d: %v\n", job.ID, finishErr)
+	}
+	return transcript, err
+}
+
+// saveUpload writes uploaded media to a temporary file, keeping the
+// extension of filename so the input checks recognize it
+func saveUpload(r io.Reader, filename string) (string, func(), error) {
+	file, err := os.CreateTemp("", "stt-upload-*"+filepath.Ext(filename))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+	cleanup := func() { os.Remove(file.Name()) }
+
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+	return file.Name(), cleanup, nil
+}
+
+// uploadErrorStatus reports uploads cut off by the size limit as 413,
+// and other failures with the given status
+func uploadErrorStatus(err error, status int) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return status
+}
+
+// parseByteSize reads a size such as 500MB or 2G; a plain number is bytes
+func parseByteSize(input string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(input))
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"GB", 1 << 30}, {"G", 1 << 30},
+		{"MB", 1 << 20}, {"M", 1 << 20},
+		{"KB", 1 << 10}, {"K", 1 << 10},
+		{"B", 1},
+	}
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, multiplier = strings.TrimSuffix(value, unit.suffix), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 500MB or 2GB)", input)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// outputContentTypes maps output formats to HTTP content types
+var outputContentTypes = map[string]string{
+	"txt":     "text/plain; charset=utf-8",
+	"srt":     "application/x-subrip; charset=utf-8",
+	"vtt":     "text/vtt; charset=utf-8",
+	"ttml":    "application/ttml+xml",
+	"dfxp":    "application/ttaf+xml",
+	"json":    "application/json",
+	"minutes": "text/markdown; charset=utf-8",
+	"edl":     "text/plain; charset=utf-8",
+	"markers": "application/xml",
+}
+
+// httpEvent is one line of a streamed HTTP response
+type httpEvent struct {
+	Stage   string      `json:"stage,omitempty"`
+	Segment *Segment    `json:"segment,omitempty"`
+	Result  *Transcript `json:"result,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// handleTranscribe accepts an upload (multipart "file" field or a raw body)
+// and responds with the transcript, or streams NDJSON events with ?stream=1
+func (s *transcriptionServer) handleTranscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	cfg, err := s.requestConfig(query.Get("model"), query.Get("language"), query.Get("backend"), query.Get("translate") == "true")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	priority, err := parsePriority(query.Get("priority"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if !isOutputFormat(format) || format == "anki" {
+		http.Error(w, fmt.Sprintf("unknown output format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	if s.maxUpload > 0 {
+		if r.ContentLength > s.maxUpload {
+			http.Error(w, fmt.Sprintf("upload is larger than %s", formatSize(s.maxUpload)), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, s.maxUpload)
+	}
+
+	job := &jobRecord{Input: query.Get("input"), Priority: priority, Config: cfg}
+	input := job.Input
+	if input != "" {
+		if err := s.checkInput(input); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	} else {
+		body, filename := io.Reader(r.Body), query.Get("filename")
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			file, header, err := r.FormFile("file")
+			if err != nil {
+				http.Error(w, "missing \"file\" field: "+err.Error(), uploadErrorStatus(err, http.StatusBadRequest))
+				return
+			}
+			defer file.Close()
+			body, filename = file, header.Filename
+		}
+		path, cleanup, err := saveUpload(body, filename)
+		if err != nil {
+			http.Error(w, err.Error(), uploadErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		defer cleanup()
+		input, job.Filename = path, filename
+	}
+
+	if query.Get("stream") == "" {
+		transcript, err := s.transcribe(r.Context(), job, input, PipelineHooks{})
+		if errors.Is(err, errDraining) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if errors.Is(err, errTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		data, err := renderFormat(transcript, format, cfg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", outputContentTypes[format])
+		w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	send := func(event httpEvent) {
+		encoder.Encode(event)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	transcript, err := s.transcribe(r.Context(), job, input, PipelineHooks{
+		Progress: func(stage string) { send(httpEvent{Stage: stage}) },
+		Segment:  func(seg Segment) { send(httpEvent{Segment: &seg}) },
+	})
+	if err != nil {
+		send(httpEvent{Error: err.Error()})
+		return
+	}
+	send(httpEvent{Result: transcript})
+}
+
+// runServe starts the HTTP and gRPC APIs and serves until interrupted
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	cfg, err := addConfigFlags(fs)
+	if err != nil {
+		return err
+	}
+	httpAddr := fs.String("http", "127.0.0.1:8080", "address for the HTTP API (empty to disable)")
+	grpcAddr := fs.String("grpc", "", "address for the gRPC API, e.g. 127.0.0.1:9090 (empty to disable)")
+	allowPaths := fs.Bool("allow-paths", false, "let clients transcribe server-side paths and URLs")
+	metricsAddr := fs.String("metrics", "", "separate address for /metrics (default: served by the HTTP API)")
+	maxJobs := fs.Int("max-jobs", 1, "number of jobs to run at the same time")
+	jobTimeout := fs.Duration("job-timeout", 0, "cancel jobs that run longer than this (0 for no limit)")
+	drainTimeout := fs.Duration("drain-timeout", 5*time.Minute, "how long to wait for running jobs on shutdown")
+	tokensFile := fs.String("tokens-file", "", "require a bearer token listed in this file, one per line")
+	oidcIssuer := fs.String("oidc-issuer", "", "accept ID tokens from this OIDC issuer URL")
+	oidcAudience := fs.String("oidc-audience", "", "audience (client ID) that OIDC tokens must be issued for")
+	maxUpload := fs.String("max-upload", "", "reject uploads larger than this, e.g. 500MB (empty for no limit)")
+	maxDuration := fs.Duration("max-duration", 0, "reject audio longer than this, e.g. 2h (0 for no limit)")
+	jobsDB := fs.String("jobs-db", "", "job history database (default: jobs.db next to the config file)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	if *httpAddr == "" && *grpcAddr == "" {
+		return fmt.Errorf("nothing to serve: pass --http and/or --grpc")
+	}
+	if cfg.LowMemory && *maxJobs > 1 {
+		fmt.Fprintf(os.Stderr, "Warning: --low-memory runs one job at a time, not %d\n", *maxJobs)
+		*maxJobs = 1
+	}
+
+	var uploadLimit int64
+	if *maxUpload != "" {
+		if uploadLimit, err = parseByteSize(*maxUpload); err != nil {
+			return err
+		}
+	}
+	auth, err := newAuthenticator(context.Background(), *tokensFile, *oidcIssuer, *oidcAudience)
+	if err != nil {
+		return err
+	}
+	if auth == nil && ((*httpAddr != "" && !isLoopback(*httpAddr)) || (*grpcAddr != "" && !isLoopback(*grpcAddr))) {
+		fmt.Println("Warning: the API is reachable from other machines without authentication (see --tokens-file)")
+	}
+
+	// Jobs left unfinished by a previous run will never complete
+	jobs, err := openJobStore(*jobsDB, cfg.History)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: job history disabled: %v\n", err)
+	}
+	defer jobs.Close()
+	if err := jobs.interruptStale("serve"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	// Old jobs expire while the server runs, not only when it starts
+	if days := cfg.History.RetentionDays; days > 0 {
+		go func() {
+			for range time.Tick(time.Hour) {
+				if _, err := jobs.prune(retentionCutoff(days)); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to remove old jobs: %v\n", err)
+				}
+			}
+		}()
+	}
+
+	server := &transcriptionServer{
+		config:      *cfg,
+		allowPaths:  *allowPaths,
+		scheduler:   newScheduler(*maxJobs, *jobTimeout),
+		auth:        auth,
+		maxUpload:   uploadLimit,
+		maxDuration: *maxDuration,
+		jobs:        jobs,
+	}
+	errs := make(chan error, 3)
+	registerMetrics()
+
+	var httpServer *http.Server
+	if *httpAddr != "" {
+		mux := http.NewServeMux()
+		var transcribe http.Handler = http.HandlerFunc(server.handleTranscribe)
+		if auth != nil {
+			transcribe = auth.middleware(transcribe)
+		}
+		mux.Handle("/transcribe", transcribe)
+		mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, "ok")
+		})
+		if *metricsAddr == "" {
+			mux.Handle("/metrics", promhttp.Handler())
+		}
+		httpServer = &http.Server{Addr: *httpAddr, Handler: mux}
+
+		fmt.Printf("HTTP API listening on http://%s\n", *httpAddr)
+		go func() {
+			if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}()
+	}
+
+	var metricsServer *http.Server
+	if *metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		metricsServer = &http.Server{Addr: *metricsAddr, Handler: mux}
+
+		fmt.Printf("Metrics available on http://%s/metrics\n", *metricsAddr)
+		go func() {
+			if err := metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}()
+	}
+
+	var grpcServer *grpc.Server
+	if *grpcAddr != "" {
+		listener, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return err
+		}
+		grpcServer = newGRPCServer(server)
+
+		fmt.Printf("gRPC API listening on %s\n", *grpcAddr)
+		go func() {
+			errs <- grpcServer.Serve(listener)
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		fmt.Printf("Shutting down, waiting for %d jobs (up to %s)\n", server.scheduler.pending(), *drainTimeout)
+	}
+
+	// Finish the jobs already submitted, refusing new ones meanwhile
+	drainCtx, cancel := context.WithTimeout(context.Background(), *drainTimeout)
+	defer cancel()
+	if drainErr := server.scheduler.drain(drainCtx); drainErr != nil {
+		fmt.Println(drainErr)
+	}
+
+	if httpServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
+	return err
+}
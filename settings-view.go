@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setting is one row of the settings screen. Choice settings cycle through
+// Options; toggle settings flip a boolean.
+type setting struct {
+	Label   string
+	Options []string
+	Choice  func(c *Config) *string
+	Toggle  func(c *Config) *bool
+}
+
+// settingsItems lists the rows shown on the settings screen
+var settingsItems = []setting{
+	{Label: "Model size", Options: modelSizes, Choice: func(c *Config) *string { return &c.Model }},
+	{Label: "Language", Options: languages, Choice: func(c *Config) *string { return &c.Language }},
+	{Label: "Output format", Options: outputFormats, Choice: func(c *Config) *string { return &c.OutputFormat }},
+	{Label: "Backend", Options: backendNames(), Choice: func(c *Config) *string { return &c.Backend }},
+	{Label: "Interface language", Options: uiLanguages, Choice: func(c *Config) *string { return &c.UILanguage }},
+	{Label: "Timestamps", Options: timestampStyles, Choice: func(c *Config) *string { return &c.Timestamps.Style }},
+	{Label: "Frame rate", Options: frameRates, Choice: func(c *Config) *string { return &c.Timestamps.FrameRate }},
+	{Label: "Translate to English", Toggle: func(c *Config) *bool { return &c.Translate }},
+	{Label: "Tag mixed languages", Toggle: func(c *Config) *bool { return &c.CodeSwitching }},
+	{Label: "Identify speakers", Toggle: func(c *Config) *bool { return &c.Diarize }},
+	{Label: "Tone and keywords", Toggle: func(c *Config) *bool { return &c.Analyze }},
+	{Label: "Voice band filter", Toggle: func(c *Config) *bool { return &c.VoiceFilter }},
+	{Label: "Noise reduction", Toggle: func(c *Config) *bool { return &c.Denoise }},
+	{Label: "Loudness normalization", Toggle: func(c *Config) *bool { return &c.Normalize }},
+	{Label: "Mark uncertain words", Toggle: func(c *Config) *bool { return &c.MarkLowConfidence }},
+	{Label: "Numbers as digits", Toggle: func(c *Config) *bool { return &c.FormatNumbers }},
+	{Label: "Format dates", Toggle: func(c *Config) *bool { return &c.FormatDates }},
+	{Label: "Currency symbols", Toggle: func(c *Config) *bool { return &c.FormatCurrency }},
+	{Label: "Capitalize sentences", Toggle: func(c *Config) *bool { return &c.Capitalize }},
+}
+
+// cycleOption returns the option step places away from current, wrapping
+// around. Unknown values start from the first option.
+func cycleOption(options []string, current string, step int) string {
+	index := -1
+	for i, option := range options {
+		if option == current {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return options[0]
+	}
+	return options[(index+step+len(options))%len(options)]
+}
+
+// openSettings switches to the settings screen, remembering where to return
+func (m model) openSettings() model {
+	m.prevState = m.state
+	m.state = StateSettings
+	m.settingsError = ""
+	return m
+}
+
+// updateSettings handles key presses on the settings screen
+func (m model) updateSettings(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	item := settingsItems[m.settingsCursor]
+	changed := false
+
+	switch {
+	case key.Matches(msg, keys.ForceQuit):
+		return m, tea.Quit
+	case key.Matches(msg, keys.Close):
+		m.state = m.prevState
+		return m, nil
+	case key.Matches(msg, keys.Up):
+		if m.settingsCursor > 0 {
+			m.settingsCursor--
+		}
+	case key.Matches(msg, keys.Down):
+		if m.settingsCursor < len(settingsItems)-1 {
+			m.settingsCursor++
+		}
+	case key.Matches(msg, keys.Previous):
+		if item.Choice != nil {
+			value := item.Choice(&m.config)
+			*value = cycleOption(item.Options, *value, -1)
+			changed = true
+		}
+	case key.Matches(msg, keys.Change):
+		if item.Choice != nil {
+			value := item.Choice(&m.config)
+			*value = cycleOption(item.Options, *value, 1)
+		} else {
+			value := item.Toggle(&m.config)
+			*value = !*value
+		}
+		changed = true
+	}
+
+	// Persist every change so settings survive a crash or Ctrl+C
+	if changed {
+		setUILanguage(m.config.UILanguage)
+		m = m.localize()
+		m.settingsError = ""
+		if err := m.config.save(); err != nil {
+			m.settingsError = err.Error()
+		}
+	}
+
+	return m, nil
+}
+
+// settingsView renders the settings screen
+func (m model) settingsView() string {
+	var rows []string
+	for i, item := range settingsItems {
+		var value string
+		if item.Choice != nil {
+			value = fmt.Sprintf("‹ %s ›", *item.Choice(&m.config))
+		} else if *item.Toggle(&m.config) {
+			value = "[x]"
+		} else {
+			value = "[ ]"
+		}
+
+		row := fmt.Sprintf("  %-24s %s", tr(item.Label), value)
+		if i == m.settingsCursor {
+			row = successStyle.Render(fmt.Sprintf("> %-24s %s", tr(item.Label), value))
+		}
+		rows = append(rows, row)
+	}
+
+	status := subtitleStyle.Render(tr("↑/↓ to move • ←/→ or Enter to change • Esc to go back • ? for help"))
+	if m.settingsError != "" {
+		status = errorStyle.Render(tr("Could not save settings: %s", m.settingsError))
+	}
+
+	return fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s",
+		titleStyle.Render("Speech-to-Text CLI"),
+		subtitleStyle.Render(tr("Settings")),
+		strings.Join(rows, "\n"),
+		status)
+}
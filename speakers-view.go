@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openSpeakers switches to the speaker naming screen, with one name field
+// per speaker found by diarization
+func (m model) openSpeakers() (model, tea.Cmd) {
+	m.player.Stop()
+	m.playingSegment = -1
+	m.state = StateSpeakers
+	m.status = ""
+	m.speakerCursor = 0
+	m.speakerLabels = m.transcript.speakers()
+	m.speakerInputs = make([]textinput.Model, len(m.speakerLabels))
+	for i, label := range m.speakerLabels {
+		ti := textinput.New()
+		ti.Prompt = ""
+		ti.Placeholder = label
+		ti.CharLimit = 60
+		ti.Width = 30
+		m.speakerInputs[i] = ti
+	}
+	return m, m.speakerInputs[0].Focus()
+}
+
+// moveSpeaker moves the focus to another speaker's name field
+func (m model) moveSpeaker(step int) (model, tea.Cmd) {
+	next := max(0, min(len(m.speakerInputs)-1, m.speakerCursor+step))
+	if next == m.speakerCursor {
+		return m, nil
+	}
+	m.speakerInputs[m.speakerCursor].Blur()
+	m.speakerCursor = next
+	return m, m.speakerInputs[next].Focus()
+}
+
+// applySpeakerNames renames the speakers in the transcript, and so in every
+// export, keeping the labels of speakers left unnamed
+func (m model) applySpeakerNames() model {
+	names := map[string]string{}
+	for i, label := range m.speakerLabels {
+		names[label] = m.speakerInputs[i].Value()
+	}
+	m.transcript.renameSpeakers(names)
+
+	renamed := 0
+	for _, name := range names {
+		if strings.TrimSpace(name) != "" {
+			renamed++
+		}
+	}
+	if renamed > 0 {
+		m.status = tr("Renamed %d of %d speakers", renamed, len(names))
+	}
+	return m
+}
+
+// updateSpeakers handles key presses on the speaker naming screen
+func (m model) updateSpeakers(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.ForceQuit):
+		return m, tea.Quit
+	case key.Matches(msg, keys.SkipNames), key.Matches(msg, keys.ApplyNames):
+		m.status = ""
+		if key.Matches(msg, keys.ApplyNames) {
+			m = m.applySpeakerNames()
+		}
+		m.player.Reset()
+		m.state = StateComplete
+		m = m.updateMaxScroll()
+		return m, nil
+	case key.Matches(msg, keys.PrevSpeaker):
+		return m.moveSpeaker(-1)
+	case key.Matches(msg, keys.NextSpeaker):
+		return m.moveSpeaker(1)
+	case key.Matches(msg, keys.PlaySample):
+		if !m.canPlay() {
+			m.status = tr("Playback is not available for this file")
+			return m, nil
+		}
+		samples := m.transcript.speakerSamples(m.speakerLabels[m.speakerCursor], 1)
+		cmd, err := m.player.PlayFor(m.selectedFile, samples[0].Start, samples[0].End-samples[0].Start)
+		if err != nil {
+			m.status = err.Error()
+		}
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.speakerInputs[m.speakerCursor], cmd = m.speakerInputs[m.speakerCursor].Update(msg)
+	return m, cmd
+}
+
+// speakersView renders the speaker naming screen: each speaker's name field
+// with a line they said, to recognize them by
+func (m model) speakersView() string {
+	width := m.width - 12
+
+	var rows []string
+	for i, label := range m.speakerLabels {
+		row := "  " + padRight(label, 12) + " " + m.speakerInputs[i].View()
+		if i == m.speakerCursor {
+			row = successStyle.Render("> "+padRight(label, 12)) + " " + m.speakerInputs[i].View()
+		}
+		rows = append(rows, row)
+
+		for _, sample := range m.transcript.speakerSamples(label, 1) {
+			quote := fmt.Sprintf("[%s] %q", m.config.Timestamps.display(sample.Start), strings.TrimSpace(sample.Text))
+			rows = append(rows, "    "+subtitleStyle.Render(truncate(quote, max(20, width))))
+		}
+	}
+
+	status := subtitleStyle.Render(tr("↑/↓ to move • Ctrl+P to hear a sample • Enter to apply • Esc to keep the labels"))
+	if m.status != "" {
+		status = errorStyle.Render(m.status) + "\n" + status
+	}
+
+	return fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s",
+		titleStyle.Render("Speech-to-Text CLI"),
+		subtitleStyle.Render(tr("Who is speaking? Type a name for each speaker")),
+		strings.Join(rows, "\n"),
+		status)
+}
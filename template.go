@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateData is what custom output templates see: the transcript fields
+// (.Text, .Segments, .Source, ...) plus .Stats and .Generated
+type templateData struct {
+	*Transcript
+	Stats     transcriptStats
+	Generated time.Time
+}
+
+// transcriptStats summarizes a transcript for templates
+type transcriptStats struct {
+	Words          int
+	Segments       int
+	Duration       float64 // seconds
+	WordsPerMinute float64
+}
+
+// templateFuncs are the helpers available in output templates
+var templateFuncs = template.FuncMap{
+	"clock":    formatClock,
+	"srtTime":  func(seconds float64) string { return formatTimestamp(seconds, ",") },
+	"vttTime":  func(seconds float64) string { return formatTimestamp(seconds, ".") },
+	"duration": formatDuration,
+	"seconds":  func(seconds float64) string { return TimestampConfig{Style: "seconds"}.format(seconds) },
+	"timecode": timecode,
+	"add":      func(a, b int) int { return a + b },
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"trim":     strings.TrimSpace,
+	"join":     strings.Join,
+	"date":     func(layout string, t time.Time) string { return t.Format(layout) },
+}
+
+// loadTemplate parses a user template file
+func loadTemplate(path string) (*template.Template, error) {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderTemplate executes the template at path with the transcript
+func renderTemplate(t *Transcript, path string) ([]byte, error) {
+	tmpl, err := loadTemplate(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := transcriptStats{
+		Words:    len(strings.Fields(t.Text)),
+		Segments: len(t.Segments),
+		Duration: t.Duration,
+	}
+	if stats.Duration == 0 {
+		stats.Duration = t.duration()
+	}
+	if stats.Duration > 0 {
+		stats.WordsPerMinute = float64(stats.Words) / (stats.Duration / 60)
+	}
+
+	var buf bytes.Buffer
+	data := templateData{Transcript: t, Stats: stats, Generated: time.Now()}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// templateExtension derives the output extension from a template name:
+// minutes.md.tmpl produces .md files, and anything else produces .txt
+func templateExtension(path string) string {
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".tmpl"), ".tpl")
+	if ext := filepath.Ext(name); ext != "" {
+		return ext
+	}
+	return ".txt"
+}
@@ -0,0 +1,520 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Spoken-form rewriting for English transcripts: "twenty five percent" →
+// "25%", "five dollars" → "$5", "march third" → "March 3". Each rule is a
+// separate setting, since captions and documents follow different styles.
+
+var (
+	numberUnits = map[string]int{
+		"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+		"six": 6, "seven": 7, "eight": 8, "nine": 9,
+	}
+	numberTeens = map[string]int{
+		"ten": 10, "eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14,
+		"fifteen": 15, "sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19,
+	}
+	numberTens = map[string]int{
+		"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50,
+		"sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+	}
+	numberScales = map[string]int{
+		"thousand": 1000, "million": 1000000, "billion": 1000000000,
+	}
+	ordinalWords = map[string]int{
+		"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5,
+		"sixth": 6, "seventh": 7, "eighth": 8, "ninth": 9, "tenth": 10,
+		"eleventh": 11, "twelfth": 12, "thirteenth": 13, "fourteenth": 14,
+		"fifteenth": 15, "sixteenth": 16, "seventeenth": 17, "eighteenth": 18,
+		"nineteenth": 19, "twentieth": 20, "thirtieth": 30,
+	}
+	currencySymbols = map[string]string{
+		"dollar": "$", "dollars": "$", "euro": "€", "euros": "€", "pound": "£", "pounds": "£",
+	}
+	monthNames = []string{
+		"january", "february", "march", "april", "may", "june",
+		"july", "august", "september", "october", "november", "december",
+	}
+	weekdayNames = []string{
+		"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday",
+	}
+)
+
+// token is a word with the punctuation around it kept apart
+type token struct {
+	lead, word, trail string
+}
+
+func splitToken(s string) token {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }
+	start := strings.IndexFunc(s, isWord)
+	if start < 0 {
+		return token{lead: s}
+	}
+	end := strings.LastIndexFunc(s, isWord)
+	_, size := utf8.DecodeRuneInString(s[end:])
+	return token{lead: s[:start], word: s[start : end+size], trail: s[end+size:]}
+}
+
+func (t token) String() string { return t.lead + t.word + t.trail }
+
+// key is the lowercase word used for matching
+func (t token) key() string { return strings.ToLower(t.word) }
+
+// numberWords splits a token into number words, so "twenty-five" counts as
+// two; it returns nil if any part isn't a number word
+func numberWords(t token) []string {
+	parts := strings.Split(t.key(), "-")
+	for _, part := range parts {
+		_, unit := numberUnits[part]
+		_, teen := numberTeens[part]
+		_, tens := numberTens[part]
+		_, scale := numberScales[part]
+		if !unit && !teen && !tens && !scale && part != "hundred" {
+			return nil
+		}
+	}
+	return parts
+}
+
+// parseNumber reads the longest spoken number at the start of tokens,
+// returning its value and how many tokens it used (0 if none). Words that
+// can't continue the number, as in "one two", end it.
+func parseNumber(tokens []token) (value, used int) {
+	total, current := 0, 0
+	last := "" // kind of the previous word: unit, teen, tens, hundred or scale
+	for i, t := range tokens {
+		// "a hundred", "one hundred and five"
+		if t.key() == "a" && i+1 < len(tokens) && i == 0 {
+			if next := numberWords(tokens[i+1]); len(next) == 1 && (next[0] == "hundred" || numberScales[next[0]] > 0) {
+				current, last = 1, "unit"
+				used = i + 1
+				continue
+			}
+		}
+		if t.key() == "and" && (last == "hundred" || last == "scale") && i+1 < len(tokens) && numberWords(tokens[i+1]) != nil {
+			continue
+		}
+
+		words := numberWords(t)
+		if words == nil {
+			break
+		}
+		valid := true
+		c, tot, l := current, total, last
+		for _, w := range words {
+			switch {
+			case numberUnits[w] > 0 || w == "zero":
+				if l == "unit" || l == "teen" || (w == "zero" && l != "") {
+					valid = false
+				}
+				c += numberUnits[w]
+				l = "unit"
+			case numberTeens[w] > 0:
+				if l == "unit" || l == "teen" || l == "tens" {
+					valid = false
+				}
+				c += numberTeens[w]
+				l = "teen"
+			case numberTens[w] > 0:
+				if l == "unit" || l == "teen" || l == "tens" {
+					valid = false
+				}
+				c += numberTens[w]
+				l = "tens"
+			case w == "hundred":
+				if l == "" || l == "hundred" || c >= 100 {
+					valid = false
+				}
+				c *= 100
+				l = "hundred"
+			default:
+				if l == "" || l == "scale" {
+					valid = false
+				}
+				tot += c * numberScales[w]
+				c = 0
+				l = "scale"
+			}
+		}
+		if !valid {
+			break
+		}
+		current, total, last = c, tot, l
+		used = i + 1
+
+		// Punctuation ends the number: "five, six"
+		if t.trail != "" {
+			break
+		}
+	}
+	if used == 0 || last == "" {
+		return 0, 0
+	}
+	return total + current, used
+}
+
+// formatNumber writes a number with thousands separators from 10,000 up,
+// leaving four-digit numbers such as years alone
+func formatNumber(n int) string {
+	s := strconv.Itoa(n)
+	if n < 10000 {
+		return s
+	}
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// parseDigits reads a token of digits, with or without separators
+func parseDigits(t token) (int, bool) {
+	n, err := strconv.Atoi(strings.ReplaceAll(t.word, ",", ""))
+	return n, err == nil && t.word != ""
+}
+
+// parseOrdinal reads "fifth", "twenty-first", "twenty first" or "5th"
+func parseOrdinal(tokens []token) (value, used int) {
+	if len(tokens) == 0 {
+		return 0, 0
+	}
+	key := tokens[0].key()
+	for _, suffix := range []string{"st", "nd", "rd", "th"} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(key, suffix)); err == nil && strings.HasSuffix(key, suffix) {
+			return n, 1
+		}
+	}
+
+	parts := strings.Split(key, "-")
+	if len(parts) == 2 && numberTens[parts[0]] > 0 && ordinalWords[parts[1]] > 0 && ordinalWords[parts[1]] < 10 {
+		return numberTens[parts[0]] + ordinalWords[parts[1]], 1
+	}
+	if n := ordinalWords[key]; n > 0 {
+		return n, 1
+	}
+	if tens := numberTens[key]; tens > 0 && tokens[0].trail == "" && len(tokens) > 1 {
+		if n := ordinalWords[tokens[1].key()]; n > 0 && n < 10 {
+			return tens + n, 2
+		}
+	}
+	return 0, 0
+}
+
+// parseYear reads a year said as a number ("two thousand five") or in
+// pairs ("nineteen ninety nine", "twenty twenty-four")
+func parseYear(tokens []token) (value, used int) {
+	if len(tokens) > 0 {
+		if n, ok := parseDigits(tokens[0]); ok && n >= 1000 && n < 3000 {
+			return n, 1
+		}
+	}
+
+	century, n := parseNumber(tokens)
+	if n > 0 && century >= 10 && century < 100 && tokens[n-1].trail == "" {
+		if rest, m := parseNumber(tokens[n:]); m > 0 && rest >= 10 && rest < 100 {
+			return century*100 + rest, n + m
+		}
+	}
+	if century >= 1000 && century < 3000 {
+		return century, n
+	}
+	return 0, 0
+}
+
+// monthIndex returns the month number of a month name, or 0. "May" is only
+// a month when a day follows, which the callers check.
+func monthIndex(t token) int {
+	for i, name := range monthNames {
+		if t.key() == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// capitalizeFirst upper-cases the first letter of a word
+func capitalizeFirst(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+// rewriteDates turns "march third, twenty twenty-four" and "the third of
+// march" into "March 3, 2024" and "March 3"
+func rewriteDates(tokens []token) []token {
+	var out []token
+	for i := 0; i < len(tokens); {
+		// Month, day, optional year
+		if month := monthIndex(tokens[i]); month > 0 && tokens[i].trail == "" {
+			day, n := parseOrdinal(tokens[i+1:])
+			spelled := n > 0
+			if n == 0 {
+				if d, ok := parseDigits(safeToken(tokens, i+1)); ok {
+					day, n = d, 1
+				}
+			}
+			end := i + 1 + n
+			year, m := 0, 0
+			if n > 0 && (tokens[end-1].trail == "" || tokens[end-1].trail == ",") {
+				year, m = parseYear(tokens[end:])
+			}
+
+			// "you may second that" isn't a date
+			if n > 0 && day >= 1 && day <= 31 && !(month == 5 && spelled && m == 0) {
+				date := token{lead: tokens[i].lead, word: capitalizeFirst(tokens[i].key()) + " " + strconv.Itoa(day), trail: tokens[end-1].trail}
+				if m > 0 {
+					date.word += ", " + strconv.Itoa(year)
+					end += m
+					date.trail = tokens[end-1].trail
+				}
+				out = append(out, date)
+				i = end
+				continue
+			}
+		}
+
+		// "the third of march"
+		if tokens[i].key() == "the" {
+			day, n := parseOrdinal(tokens[i+1:])
+			if n > 0 && day <= 31 && safeToken(tokens, i+1+n).key() == "of" {
+				monthAt := i + 2 + n
+				if month := monthIndex(safeToken(tokens, monthAt)); month > 0 {
+					out = append(out, token{
+						lead:  tokens[i].lead,
+						word:  capitalizeFirst(monthNames[month-1]) + " " + strconv.Itoa(day),
+						trail: tokens[monthAt].trail,
+					})
+					i = monthAt + 1
+					continue
+				}
+			}
+		}
+
+		out = append(out, tokens[i])
+		i++
+	}
+	return out
+}
+
+// safeToken returns tokens[i], or an empty token past the end
+func safeToken(tokens []token, i int) token {
+	if i < len(tokens) {
+		return tokens[i]
+	}
+	return token{}
+}
+
+// rewriteNumbers turns spoken numbers into digits, keeping one to nine as
+// words unless a unit follows, and "percent" into "%". With currency,
+// "five dollars" becomes "$5" and "five dollars and ten cents" "$5.10",
+// whether or not other numbers are rewritten.
+func rewriteNumbers(tokens []token, numbers, currency bool) []token {
+	var out []token
+	for i := 0; i < len(tokens); {
+		value, n := parseNumber(tokens[i:])
+		digits := ""
+		if n > 0 {
+			digits = formatNumber(value)
+			// "three point one four"
+			if numbers && tokens[i+n-1].trail == "" && safeToken(tokens, i+n).key() == "point" {
+				var decimals strings.Builder
+				j := i + n + 1
+				for j < len(tokens) {
+					words := numberWords(tokens[j])
+					if len(words) != 1 || (numberUnits[words[0]] == 0 && words[0] != "zero") {
+						break
+					}
+					decimals.WriteString(strconv.Itoa(numberUnits[words[0]]))
+					j++
+					if tokens[j-1].trail != "" {
+						break
+					}
+				}
+				if decimals.Len() > 0 {
+					digits += "." + decimals.String()
+					n = j - i
+				}
+			}
+		} else if d, ok := parseDigits(tokens[i]); ok {
+			value, n, digits = d, 1, tokens[i].word
+		}
+		if n == 0 {
+			out = append(out, tokens[i])
+			i++
+			continue
+		}
+
+		first, last := tokens[i], tokens[i+n-1]
+		next := safeToken(tokens, i+n)
+		end := i + n
+
+		switch {
+		case numbers && last.trail == "" && (next.key() == "percent" || next.key() == "per" && safeToken(tokens, end+1).key() == "cent"):
+			if next.key() == "per" {
+				end++
+			}
+			out = append(out, token{lead: first.lead, word: digits + "%", trail: tokens[end].trail})
+			i = end + 1
+			continue
+
+		case currency && last.trail == "" && currencySymbols[next.key()] != "":
+			amount := token{lead: first.lead, word: currencySymbols[next.key()] + digits, trail: next.trail}
+			end++
+			// "and fifty cents"
+			if next.trail == "" && safeToken(tokens, end).key() == "and" && !strings.Contains(digits, ".") {
+				cents, m := parseNumber(tokens[end+1:])
+				if m == 0 {
+					if d, ok := parseDigits(safeToken(tokens, end+1)); ok {
+						cents, m = d, 1
+					}
+				}
+				if m > 0 && cents < 100 && safeToken(tokens, end+1+m).key() == "cents" && tokens[end+m].trail == "" {
+					amount.word += "." + strconv.Itoa(cents/10) + strconv.Itoa(cents%10)
+					amount.trail = tokens[end+1+m].trail
+					end += m + 2
+				}
+			}
+			out = append(out, amount)
+			i = end
+			continue
+		}
+
+		// Small numbers read better as words in running text
+		if !numbers || value < 10 && n == 1 && !strings.Contains(digits, ".") {
+			out = append(out, tokens[i:end]...)
+		} else {
+			out = append(out, token{lead: first.lead, word: digits, trail: last.trail})
+		}
+		i = end
+	}
+	return out
+}
+
+// endsSentence reports whether text ends with terminal punctuation
+func endsSentence(text string) bool {
+	text = strings.TrimRight(text, `"')]`)
+	return strings.HasSuffix(text, ".") || strings.HasSuffix(text, "?") || strings.HasSuffix(text, "!")
+}
+
+// properNouns finds words the model capitalized in the middle of a
+// sentence more often than not, so their other occurrences can match
+func properNouns(segments []Segment) map[string]string {
+	upper := map[string]int{}
+	lower := map[string]int{}
+	spelling := map[string]string{}
+	for _, seg := range segments {
+		sentenceStart := true
+		for _, field := range strings.Fields(seg.Text) {
+			t := splitToken(field)
+			if t.word != "" && !sentenceStart {
+				r, _ := utf8.DecodeRuneInString(t.word)
+				if unicode.IsUpper(r) {
+					upper[t.key()]++
+					spelling[t.key()] = t.word
+				} else if unicode.IsLower(r) {
+					lower[t.key()]++
+				}
+			}
+			sentenceStart = endsSentence(field)
+		}
+	}
+
+	nouns := map[string]string{}
+	for key, n := range upper {
+		if n > lower[key] && key != "i" {
+			nouns[key] = spelling[key]
+		}
+	}
+	for _, name := range append(monthNames, weekdayNames...) {
+		if name != "may" {
+			nouns[name] = capitalizeFirst(name)
+		}
+	}
+	return nouns
+}
+
+// capitalize upper-cases sentence starts, "I" and proper nouns in a
+// segment; startsSentence says whether the previous segment ended one
+func capitalize(tokens []token, startsSentence bool, nouns map[string]string) []token {
+	for i, t := range tokens {
+		key := t.key()
+		switch {
+		case t.word == "":
+		case startsSentence:
+			tokens[i].word = capitalizeFirst(t.word)
+		case key == "i" || strings.HasPrefix(key, "i'"):
+			tokens[i].word = capitalizeFirst(t.word)
+		case nouns[key] != "":
+			tokens[i].word = nouns[key]
+		}
+		if t.word != "" {
+			startsSentence = false
+		}
+		if endsSentence(t.trail) {
+			startsSentence = true
+		}
+	}
+	return tokens
+}
+
+// applyTextFormatting rewrites the transcript's segments and text with the
+// enabled formatting rules. Number, date and currency rules only apply to
+// English. Rewritten segments keep their word timings, which no longer
+// line up with the text and are ignored for confidence highlighting.
+func applyTextFormatting(t *Transcript, cfg Config) {
+	english := t.Language == "en" || cfg.Translate
+	numbers := cfg.FormatNumbers && english
+	dates := cfg.FormatDates && english
+	currency := cfg.FormatCurrency && english
+	if !numbers && !dates && !currency && !cfg.Capitalize {
+		return
+	}
+
+	var nouns map[string]string
+	if cfg.Capitalize {
+		nouns = properNouns(t.Segments)
+	}
+
+	startsSentence := true
+	texts := make([]string, len(t.Segments))
+	for i, seg := range t.Segments {
+		fields := strings.Fields(seg.Text)
+		tokens := make([]token, len(fields))
+		for j, field := range fields {
+			tokens[j] = splitToken(field)
+		}
+
+		if dates {
+			tokens = rewriteDates(tokens)
+		}
+		if numbers || currency {
+			tokens = rewriteNumbers(tokens, numbers, currency)
+		}
+		if cfg.Capitalize {
+			tokens = capitalize(tokens, startsSentence, nouns)
+		}
+
+		words := make([]string, len(tokens))
+		for j, tok := range tokens {
+			words[j] = tok.String()
+		}
+		t.Segments[i].Text = strings.Join(words, " ")
+		texts[i] = t.Segments[i].Text
+		if len(fields) > 0 {
+			startsSentence = endsSentence(fields[len(fields)-1])
+		}
+	}
+
+	if len(texts) > 0 {
+		t.Text = strings.Join(texts, " ")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+)
+
+// textWidth returns how many terminal columns text takes: CJK characters
+// take two, combining marks and joined emoji none of their own
+func textWidth(text string) int {
+	return uniseg.StringWidth(text)
+}
+
+// graphemes splits text into the characters a reader sees, keeping a
+// letter together with its accents and emoji with their modifiers
+func graphemes(text string) []string {
+	var clusters []string
+	state := -1
+	for text != "" {
+		var cluster string
+		cluster, text, _, state = uniseg.FirstGraphemeClusterInString(text, state)
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+// hasWideText reports whether text contains double-width characters. Such
+// scripts are written without spaces, so lines may break between any two
+// characters.
+func hasWideText(text string) bool {
+	state := -1
+	for text != "" {
+		var width int
+		_, text, width, state = uniseg.FirstGraphemeClusterInString(text, state)
+		if width > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// isRTL reports whether text is written right to left, judged by its first
+// letter as terminals do for a paragraph
+func isRTL(text string) bool {
+	for _, r := range text {
+		if unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana, unicode.Nko) {
+			return true
+		}
+		if unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return false
+}
+
+// truncate shortens s to width columns, marking the cut with "…". It cuts
+// between characters as displayed, so accents and wide characters stay
+// whole.
+func truncate(s string, width int) string {
+	if textWidth(s) <= width {
+		return s
+	}
+	limit := width - 1 // room for the ellipsis
+	if width <= 1 {
+		limit = width
+	}
+
+	var b strings.Builder
+	used, state := 0, -1
+	for rest := s; rest != ""; {
+		var cluster string
+		var w int
+		cluster, rest, w, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		if used+w > limit {
+			break
+		}
+		b.WriteString(cluster)
+		used += w
+	}
+	if width > 1 {
+		b.WriteString("…")
+	}
+	return b.String()
+}
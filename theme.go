@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ThemeConfig overrides the default color palette. Colors are hex values
+// such as "#7D56F4" or ANSI color numbers; empty values keep the default.
+type ThemeConfig struct {
+	Title    string `json:"title,omitempty"`
+	Subtitle string `json:"subtitle,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Border   string `json:"border,omitempty"`
+	Success  string `json:"success,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Warning  string `json:"warning,omitempty"`
+	NoColor  bool   `json:"no_color,omitempty"`
+	ASCII    bool   `json:"ascii,omitempty"`
+}
+
+// Default palette, adapting to light and dark terminal backgrounds
+var (
+	defaultTitleColor    = lipgloss.AdaptiveColor{Light: "#5A3FD0", Dark: "#7D56F4"}
+	defaultTitleText     = lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#FAFAFA"}
+	defaultSubtitleColor = lipgloss.AdaptiveColor{Light: "#5F5F5F", Dark: "#999999"}
+	defaultTextColor     = lipgloss.AdaptiveColor{Light: "#1C1C1C", Dark: "#F8F8F2"}
+	defaultBorderColor   = lipgloss.AdaptiveColor{Light: "#5A56E0", Dark: "#6272A4"}
+	defaultSuccessColor  = lipgloss.AdaptiveColor{Light: "#007A3D", Dark: "#50FA7B"}
+	defaultErrorColor    = lipgloss.AdaptiveColor{Light: "#C4001A", Dark: "#FF5555"}
+	defaultWarningColor  = lipgloss.AdaptiveColor{Light: "#AF5F00", Dark: "#FFB86C"}
+)
+
+var (
+	titleStyle         lipgloss.Style
+	subtitleStyle      lipgloss.Style
+	errorStyle         lipgloss.Style
+	successStyle       lipgloss.Style
+	transcriptionStyle lipgloss.Style
+	helpStyle          lipgloss.Style
+	buttonStyle        lipgloss.Style
+	highlightStyle     lipgloss.Style
+	lowConfidenceStyle lipgloss.Style
+	diffRemovedStyle   lipgloss.Style
+	diffAddedStyle     lipgloss.Style
+
+	spinnerType    = spinner.Dot
+	waveformRamp   = "▁▂▃▄▅▆▇█"
+	selectedMarker = "▌ "
+	doneMarker     = "✓"
+)
+
+// themeColor returns the override color if set, otherwise the fallback
+func themeColor(override string, fallback lipgloss.TerminalColor) lipgloss.TerminalColor {
+	if override == "" {
+		return fallback
+	}
+	return lipgloss.Color(override)
+}
+
+// applyTheme builds the styles from the theme settings. Color is also
+// disabled when the NO_COLOR environment variable is set.
+func applyTheme(theme ThemeConfig) {
+	noColor := theme.NoColor || os.Getenv("NO_COLOR") != ""
+
+	color := func(override string, fallback lipgloss.TerminalColor) lipgloss.TerminalColor {
+		if noColor {
+			return lipgloss.NoColor{}
+		}
+		return themeColor(override, fallback)
+	}
+
+	border := lipgloss.RoundedBorder()
+	spinnerType = spinner.Dot
+	waveformRamp = "▁▂▃▄▅▆▇█"
+	selectedMarker = "▌ "
+	doneMarker = "✓"
+	if theme.ASCII {
+		border = lipgloss.ASCIIBorder()
+		spinnerType = spinner.Line
+		waveformRamp = "_.-=+*#@"
+		selectedMarker = "> "
+		doneMarker = "+"
+	}
+
+	titleStyle = lipgloss.NewStyle().
+		Foreground(color("", defaultTitleText)).
+		Background(color(theme.Title, defaultTitleColor)).
+		Padding(0, 1)
+	if noColor {
+		// Keep the title distinguishable without colors
+		titleStyle = titleStyle.Bold(true).Underline(true)
+	}
+
+	subtitleStyle = lipgloss.NewStyle().
+		Foreground(color(theme.Subtitle, defaultSubtitleColor))
+
+	errorStyle = lipgloss.NewStyle().
+		Foreground(color(theme.Error, defaultErrorColor))
+
+	successStyle = lipgloss.NewStyle().
+		Foreground(color(theme.Success, defaultSuccessColor))
+
+	transcriptionStyle = lipgloss.NewStyle().
+		Foreground(color(theme.Text, defaultTextColor)).
+		Border(border).
+		BorderForeground(color(theme.Border, defaultBorderColor)).
+		Padding(1, 2).
+		Width(80)
+
+	buttonStyle = lipgloss.NewStyle().
+		Foreground(color(theme.Border, defaultBorderColor)).
+		Bold(true)
+
+	highlightStyle = lipgloss.NewStyle().
+		Foreground(color(theme.Success, defaultSuccessColor)).
+		Bold(true)
+	if noColor {
+		highlightStyle = highlightStyle.Reverse(true)
+	}
+
+	lowConfidenceStyle = lipgloss.NewStyle().
+		Foreground(color(theme.Warning, defaultWarningColor))
+	if noColor {
+		lowConfidenceStyle = lowConfidenceStyle.Underline(true)
+	}
+
+	diffRemovedStyle = lipgloss.NewStyle().
+		Foreground(color(theme.Error, defaultErrorColor))
+	diffAddedStyle = lipgloss.NewStyle().
+		Foreground(color(theme.Success, defaultSuccessColor))
+	if noColor {
+		diffRemovedStyle = diffRemovedStyle.Underline(true)
+		diffAddedStyle = diffAddedStyle.Underline(true)
+	}
+
+	helpStyle = lipgloss.NewStyle().
+		Border(border).
+		BorderForeground(color(theme.Border, defaultBorderColor)).
+		Padding(1, 2)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// timestampStyles are the ways times are shown in the viewer and written in
+// exports: clock as HH:MM:SS, seconds as a decimal number and frames as
+// SMPTE timecode at the frame rate, as video editors show it
+var timestampStyles = []string{"clock", "seconds", "frames"}
+
+// frameRates are the common video frame rates. The NTSC rates 29.97 and
+// 59.94 use drop-frame timecode, which stays in step with the clock.
+var frameRates = []string{"23.976", "24", "25", "29.97", "30", "50", "59.94", "60"}
+
+// frameRate is a parsed frame rate: the real rate, the frames counted per
+// timecode second and whether frame numbers are dropped to match the clock
+type frameRate struct {
+	fps     float64
+	nominal int
+	drop    bool
+}
+
+// parseFrameRate parses a frame rate such as 25 or 29.97
+func parseFrameRate(rate string) (frameRate, error) {
+	fps, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil || fps <= 0 || fps > 1000 {
+		return frameRate{}, fmt.Errorf("invalid frame rate %q (use e.g. 25 or 29.97)", rate)
+	}
+	nominal := int(math.Round(fps))
+	if nominal < 1 {
+		return frameRate{}, fmt.Errorf("invalid frame rate %q: timecode needs at least 1 frame per second", rate)
+	}
+	r := frameRate{fps: fps, nominal: nominal}
+	r.drop = r.ntsc() && nominal%30 == 0
+	return r, nil
+}
+
+// ntsc reports whether the rate is a whole rate slowed by 1000/1001, as
+// 23.976, 29.97 and 59.94 are
+func (r frameRate) ntsc() bool {
+	return r.fps != float64(r.nominal) && math.Abs(r.fps-float64(r.nominal)*1000/1001) < 0.01
+}
+
+// frames returns the number of the frame showing at seconds
+func (r frameRate) frames(seconds float64) int {
+	return int(math.Floor(math.Max(0, seconds)*r.fps + 1e-6))
+}
+
+// timecode formats a frame number as HH:MM:SS:FF. Drop-frame timecode
+// skips frame numbers 0 and 1 (0 to 3 at 59.94) at the start of every
+// minute except each tenth, and separates the frames with a semicolon.
+func (r frameRate) timecode(frame int) string {
+	sep := ":"
+	if r.drop {
+		dropped := r.nominal / 15
+		perMinute := r.nominal*60 - dropped
+		perTenMinutes := perMinute*10 + dropped
+		tens, rest := frame/perTenMinutes, frame%perTenMinutes
+		frame += dropped * 9 * tens
+		if rest > dropped {
+			frame += dropped * ((rest - dropped) / perMinute)
+		}
+		sep = ";"
+	}
+	ff := frame % r.nominal
+	total := frame / r.nominal
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d", total/3600, total%3600/60, total%60, sep, ff)
+}
+
+// timecode formats seconds as timecode at a frame rate, for templates
+func timecode(seconds float64, rate string) (string, error) {
+	r, err := parseFrameRate(rate)
+	if err != nil {
+		return "", err
+	}
+	return r.timecode(r.frames(seconds)), nil
+}
+
+// dropFrameName is how EDLs and editors name the timecode's counting mode
+func (r frameRate) dropFrameName() string {
+	if r.drop {
+		return "DROP FRAME"
+	}
+	return "NON-DROP FRAME"
+}
+
+// TimestampConfig sets how times are shown in the viewer and exports
+type TimestampConfig struct {
+	Style     string `json:"style"`      // one of timestampStyles
+	FrameRate string `json:"frame_rate"` // for frames and the editor exports
+}
+
+// validate checks the style and frame rate
+func (c TimestampConfig) validate() error {
+	known := false
+	for _, style := range timestampStyles {
+		known = known || style == c.Style
+	}
+	if !known {
+		return fmt.Errorf("unknown timestamp style %q (available: %s)", c.Style, strings.Join(timestampStyles, ", "))
+	}
+	_, err := parseFrameRate(c.FrameRate)
+	return err
+}
+
+// rate returns the parsed frame rate, or 25 fps for a bad one
+func (c TimestampConfig) rate() frameRate {
+	r, err := parseFrameRate(c.FrameRate)
+	if err != nil {
+		r, _ = parseFrameRate("25")
+	}
+	return r
+}
+
+// format writes a time in the configured style, as exports use it
+func (c TimestampConfig) format(seconds float64) string {
+	switch c.Style {
+	case "seconds":
+		return strconv.FormatFloat(seconds, 'f', 2, 64)
+	case "frames":
+		r := c.rate()
+		return r.timecode(r.frames(seconds))
+	}
+	return formatClock(seconds)
+}
+
+// display writes a time in the configured style for the viewer, with the
+// interface language's decimal separator
+func (c TimestampConfig) display(seconds float64) string {
+	stamp := c.format(seconds)
+	if c.Style == "seconds" {
+		stamp = strings.Replace(stamp, ".", tr("."), 1)
+	}
+	return stamp
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestFrameRateTimecode(t *testing.T) {
+	tests := []struct {
+		rate  string
+		frame int
+		want  string
+	}{
+		{"25", 0, "00:00:00:00"},
+		{"25", 91537, "01:01:01:12"},
+		{"23.976", 24, "00:00:01:00"},
+		{"30", 1800, "00:01:00:00"},
+
+		// 29.97 drops frame numbers 0 and 1 at each minute but every tenth
+		{"29.97", 1799, "00:00:59;29"},
+		{"29.97", 1800, "00:01:00;02"},
+		{"29.97", 3597, "00:01:59;29"},
+		{"29.97", 3598, "00:02:00;02"},
+		{"29.97", 17981, "00:09:59;29"},
+		{"29.97", 17982, "00:10:00;00"},
+		{"29.97", 17983, "00:10:00;01"},
+		{"29.97", 19781, "00:10:59;29"},
+		{"29.97", 19782, "00:11:00;02"},
+		{"29.97", 107892, "01:00:00;00"},
+
+		// 59.94 drops 0 to 3
+		{"59.94", 3599, "00:00:59;59"},
+		{"59.94", 3600, "00:01:00;04"},
+		{"59.94", 35964, "00:10:00;00"},
+	}
+	for _, test := range tests {
+		r, err := parseFrameRate(test.rate)
+		if err != nil {
+			t.Fatalf("parseFrameRate(%q): %v", test.rate, err)
+		}
+		if got := r.timecode(test.frame); got != test.want {
+			t.Errorf("frame %d at %s fps = %s, want %s", test.frame, test.rate, got, test.want)
+		}
+	}
+}
+
+func TestParseFrameRate(t *testing.T) {
+	for _, rate := range []string{"", "abc", "0", "-25", "0.4", "5000"} {
+		if _, err := parseFrameRate(rate); err == nil {
+			t.Errorf("parseFrameRate(%q) accepted a bad rate", rate)
+		}
+	}
+	for rate, drop := range map[string]bool{"23.976": false, "24": false, "29.97": true, "30": false, "30.4": false, "59.94": true, "1": false} {
+		r, err := parseFrameRate(rate)
+		if err != nil {
+			t.Errorf("parseFrameRate(%q): %v", rate, err)
+		} else if r.drop != drop {
+			t.Errorf("parseFrameRate(%q) drop frame = %v, want %v", rate, r.drop, drop)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// transcriptLine is one wrapped line of the transcript viewer
+type transcriptLine struct {
+	prefix    string // timestamp or indentation
+	text      string
+	segment   int // index into the transcript segments, or -1
+	firstWord int // index of the line's first word within the segment, or -1 when wrapping split a word
+}
+
+// formatClock formats seconds as HH:MM:SS for the viewer
+func formatClock(seconds float64) string {
+	total := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total%3600/60, total%60)
+}
+
+// segmentLabel renders a segment with its start time and speaker, as copied
+func segmentLabel(seg Segment, stamps TimestampConfig) string {
+	if seg.Speaker != "" {
+		return fmt.Sprintf("[%s] %s: %s", stamps.display(seg.Start), seg.Speaker, seg.Text)
+	}
+	return fmt.Sprintf("[%s] %s", stamps.display(seg.Start), seg.Text)
+}
+
+// transcriptLines wraps the transcript for display. Each segment starts on
+// its own line with its timestamp; continuation lines are indented to match.
+// A line with the speaker's name starts each speaker turn. Arabic and
+// Hebrew segments are aligned to the right edge.
+func (m model) transcriptLines() []transcriptLine {
+	width := m.width - 8 // Account for padding and border
+
+	var lines []transcriptLine
+	if m.transcript == nil || len(m.transcript.Segments) == 0 {
+		rtl := isRTL(m.transcription)
+		for _, text := range strings.Split(wrapText(m.transcription, width), "\n") {
+			line := transcriptLine{text: text, segment: -1}
+			if rtl {
+				line.prefix = strings.Repeat(" ", max(0, width-textWidth(text)))
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+
+	for i, seg := range m.transcript.Segments {
+		if seg.Speaker != "" && (i == 0 || seg.Speaker != m.transcript.Segments[i-1].Speaker) {
+			lines = append(lines, transcriptLine{text: seg.Speaker + ":", segment: -1})
+		}
+
+		stamp := fmt.Sprintf("[%s] ", m.config.Timestamps.display(seg.Start))
+		indent := strings.Repeat(" ", len(stamp))
+
+		available := width - len(stamp) - 2
+		wrapped := strings.Split(wrapText(seg.Text, available), "\n")
+		rtl := isRTL(seg.Text)
+
+		// Lines of CJK text or of a word too long to fit don't start on
+		// a word boundary, so their words can't be matched to the segment's
+		wordsKept := len(strings.Fields(strings.Join(wrapped, " "))) == len(strings.Fields(seg.Text))
+
+		firstWord := 0
+		for j, text := range wrapped {
+			prefix := indent
+			if j == 0 {
+				prefix = stamp
+			}
+			if rtl {
+				prefix += strings.Repeat(" ", max(0, available-textWidth(text)))
+			}
+			line := transcriptLine{prefix: prefix, text: text, segment: i, firstWord: firstWord}
+			if !wordsKept {
+				line.firstWord = -1
+			}
+			lines = append(lines, line)
+			firstWord += len(strings.Fields(text))
+		}
+	}
+	return lines
+}
+
+// selectSegment moves the segment cursor by step and scrolls it into view
+func (m model) selectSegment(step int) model {
+	if m.transcript == nil || len(m.transcript.Segments) == 0 {
+		return m
+	}
+	m.selectedSegment = max(0, min(len(m.transcript.Segments)-1, m.selectedSegment+step))
+
+	// Find the lines of the selected segment
+	first, last := -1, -1
+	for i, line := range m.transcriptLines() {
+		if line.segment == m.selectedSegment {
+			if first == -1 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first == -1 {
+		return m
+	}
+
+	height := m.transcriptionHeight()
+	if first < m.scrollOffset {
+		m.scrollOffset = first
+	} else if last >= m.scrollOffset+height {
+		m.scrollOffset = min(m.maxScroll, last-height+1)
+	}
+	return m
+}
+
+// copySegment copies the selected segment with its timestamp
+func (m model) copySegment() string {
+	if m.transcript == nil || len(m.transcript.Segments) == 0 {
+		return m.copyTranscript()
+	}
+	label := segmentLabel(m.transcript.Segments[m.selectedSegment], m.config.Timestamps)
+	if err := copyToClipboard(label); err != nil {
+		return err.Error()
+	}
+	return tr("Copied segment at %s", m.config.Timestamps.display(m.transcript.Segments[m.selectedSegment].Start))
+}
+
+// clipSegment saves the selected segment's audio next to the input
+func (m model) clipSegment() string {
+	if len(m.transcript.Segments) == 0 {
+		return tr("No segments to clip")
+	}
+	if isRemoteInput(m.selectedFile) {
+		return tr("Clips can only be cut from local files")
+	}
+	ffmpegPath, err := findFFmpeg()
+	if err != nil {
+		return err.Error()
+	}
+	seg := m.transcript.Segments[m.selectedSegment]
+	out := clipPath(m.selectedFile, "", seg.Start, "mp3")
+	if err := cutClip(ffmpegPath, m.selectedFile, seg.Start-clipPadding, seg.End+clipPadding, out); err != nil {
+		return err.Error()
+	}
+	return tr("Saved clip to %s", out)
+}
+
+// renderTranscriptLine styles a line of the viewer: the playing segment is
+// highlighted and words below the confidence threshold are colored
+func (m model) renderTranscriptLine(line transcriptLine) string {
+	playing := line.segment >= 0 && line.segment == m.playingSegment
+
+	var confidences []float64
+	if line.segment >= 0 && line.firstWord >= 0 {
+		confidences = m.transcript.Segments[line.segment].wordConfidences()
+	}
+	if confidences == nil {
+		if playing {
+			return line.prefix + highlightStyle.Render(line.text)
+		}
+		return line.prefix + line.text
+	}
+
+	tokens := strings.Fields(line.text)
+	for i, token := range tokens {
+		switch {
+		case confidences[line.firstWord+i] < m.config.ConfidenceThreshold:
+			tokens[i] = lowConfidenceStyle.Render(token)
+		case playing:
+			tokens[i] = highlightStyle.Render(token)
+		}
+	}
+	return line.prefix + strings.Join(tokens, " ")
+}
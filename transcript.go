@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Word is a single timed word with the model's confidence in it
+type Word struct {
+	Start       float64 `json:"start"`
+	End         float64 `json:"end"`
+	Text        string  `json:"text"`
+	Probability float64 `json:"probability"`
+}
+
+// Segment is a timed piece of a transcription
+type Segment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+	Words []Word  `json:"words,omitempty"`
+
+	// Language is set when each segment's language was detected
+	Language string `json:"language,omitempty"`
+
+	// Speaker is set by diarization: a label such as SPEAKER_00, or the
+	// name it was renamed to
+	Speaker string `json:"speaker,omitempty"`
+
+	// Sentiment is the segment's tone from -1 to 1, set by analysis
+	Sentiment *float64 `json:"sentiment,omitempty"`
+}
+
+// Transcript is the result of transcribing one file
+type Transcript struct {
+	Text     string    `json:"text"`
+	Language string    `json:"language"`
+	Segments []Segment `json:"segments"`
+
+	// Where the transcript came from, filled in by the pipeline
+	Source   string  `json:"source,omitempty"`
+	Model    string  `json:"model,omitempty"`
+	Backend  string  `json:"backend,omitempty"`
+	Duration float64 `json:"duration,omitempty"` // seconds of input audio
+
+	Analysis *Analysis `json:"analysis,omitempty"`
+
+	// Partial is set when the run stopped before the end of the audio
+	Partial bool `json:"partial,omitempty"`
+
+	// Parts lists the recordings of a merged transcript
+	Parts []TranscriptPart `json:"parts,omitempty"`
+}
+
+// duration returns the end time of the last segment in seconds
+func (t *Transcript) duration() float64 {
+	if len(t.Segments) == 0 {
+		return 0
+	}
+	return t.Segments[len(t.Segments)-1].End
+}
+
+// foreign reports whether the segment is tagged with a language other
+// than the transcript's
+func (seg Segment) foreign(t *Transcript) bool {
+	return seg.Language != "" && seg.Language != t.Language
+}
+
+// taggedText returns the transcript text with segments in other languages
+// prefixed by their language, e.g. "[es] Hola"
+func (t *Transcript) taggedText() string {
+	tagged := false
+	texts := make([]string, len(t.Segments))
+	for i, seg := range t.Segments {
+		texts[i] = seg.Text
+		if seg.foreign(t) {
+			texts[i] = "[" + seg.Language + "] " + seg.Text
+			tagged = true
+		}
+	}
+	if !tagged {
+		return t.Text
+	}
+	return strings.Join(texts, " ")
+}
+
+// outputFormats lists the formats transcripts can be saved in. anki is
+// written by writeAnkiDeck, since it also cuts audio clips.
+var outputFormats = []string{"txt", "srt", "vtt", "ttml", "dfxp", "json", "anki", "minutes", "edl", "markers"}
+
+// formatExtension returns the extension files in a format are saved with
+func formatExtension(format string) string {
+	switch format {
+	case "minutes":
+		return ".minutes.md"
+	case "markers":
+		return ".markers.xml"
+	}
+	return "." + format
+}
+
+// isOutputFormat reports whether format is a known output format
+func isOutputFormat(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// formatTranscript renders a transcript in the given output format, with
+// times written as stamps sets where the format leaves it open
+func formatTranscript(t *Transcript, format string, stamps TimestampConfig) ([]byte, error) {
+	switch format {
+	case "txt":
+		text := t.taggedText()
+		if len(t.speakers()) > 0 {
+			text = t.speakerText()
+		}
+		if t.Partial {
+			text = "[" + partialNote(t) + "]\n\n" + text
+		}
+		return []byte(text + "\n"), nil
+	case "srt":
+		return []byte(formatSRT(t)), nil
+	case "vtt":
+		return []byte(formatVTT(t)), nil
+	case "ttml", "dfxp":
+		return []byte(formatTTML(t, format)), nil
+	case "json":
+		data, err := json.MarshalIndent(t, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case "anki":
+		return nil, fmt.Errorf("anki flashcards can only be saved to files")
+	case "minutes":
+		return []byte(formatMinutes(buildMinutes(t), stamps)), nil
+	case "edl":
+		return []byte(formatEDL(t, stamps.rate())), nil
+	case "markers":
+		return []byte(formatMarkers(t, stamps.rate())), nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// wordConfidences returns the probability of each whitespace-separated
+// token of the segment text, or nil when the words don't line up with it
+func (seg Segment) wordConfidences() []float64 {
+	if len(seg.Words) == 0 || len(strings.Fields(seg.Text)) != len(seg.Words) {
+		return nil
+	}
+	confidences := make([]float64, len(seg.Words))
+	for i, word := range seg.Words {
+		confidences[i] = word.Probability
+	}
+	return confidences
+}
+
+// markLowConfidence returns a copy of the transcript where words below the
+// threshold are wrapped in the marker. In a marker containing %s, the word
+// takes its place (e.g. "[%s?]"); otherwise the marker is appended to it.
+func markLowConfidence(t *Transcript, threshold float64, marker string) *Transcript {
+	marked := *t
+	marked.Segments = make([]Segment, len(t.Segments))
+
+	var texts []string
+	for i, seg := range t.Segments {
+		if confidences := seg.wordConfidences(); confidences != nil {
+			tokens := strings.Fields(seg.Text)
+			for j, token := range tokens {
+				if confidences[j] >= threshold {
+					continue
+				}
+				if strings.Contains(marker, "%s") {
+					tokens[j] = strings.Replace(marker, "%s", token, 1)
+				} else {
+					tokens[j] = token + marker
+				}
+			}
+			seg.Text = strings.Join(tokens, " ")
+		}
+		marked.Segments[i] = seg
+		texts = append(texts, seg.Text)
+	}
+
+	if len(texts) > 0 {
+		marked.Text = strings.Join(texts, " ")
+	}
+	return &marked
+}
+
+// formatTimestamp formats seconds as HH:MM:SS followed by sep and milliseconds
+func formatTimestamp(seconds float64, sep string) string {
+	ms := int64(seconds*1000 + 0.5)
+	h := ms / 3600000
+	m := ms % 3600000 / 60000
+	s := ms % 60000 / 1000
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", h, m, s, sep, ms%1000)
+}
+
+// formatSRT renders segments as SubRip subtitles
+func formatSRT(t *Transcript) string {
+	var b strings.Builder
+	for i, seg := range t.Segments {
+		text := seg.Text
+		if seg.foreign(t) {
+			text = "[" + seg.Language + "] " + text
+		}
+		if seg.Speaker != "" {
+			text = seg.Speaker + ": " + text
+		}
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n",
+			i+1,
+			formatTimestamp(seg.Start, ","),
+			formatTimestamp(seg.End, ","),
+			text)
+	}
+	return b.String()
+}
+
+// formatVTT renders segments as WebVTT subtitles
+func formatVTT(t *Transcript) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	if t.Partial {
+		b.WriteString("NOTE " + partialNote(t) + "\n\n")
+	}
+	for _, seg := range t.Segments {
+		// WebVTT marks other languages with a lang span
+		text := seg.Text
+		if seg.foreign(t) {
+			text = "<lang " + seg.Language + ">" + text + "</lang>"
+		}
+		// and speakers with a voice span
+		if seg.Speaker != "" {
+			text = "<v " + seg.Speaker + ">" + text
+		}
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n",
+			formatTimestamp(seg.Start, "."),
+			formatTimestamp(seg.End, "."),
+			text)
+	}
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ttmlNamespaces are the namespaces of a TTML document. DFXP files use the
+// 2006 drafts' namespaces, which older players and delivery specs expect.
+var ttmlNamespaces = map[string][4]string{
+	// default, styling, metadata, parameter
+	"ttml": {
+		"http://www.w3.org/ns/ttml",
+		"http://www.w3.org/ns/ttml#styling",
+		"http://www.w3.org/ns/ttml#metadata",
+		"http://www.w3.org/ns/ttml#parameter",
+	},
+	"dfxp": {
+		"http://www.w3.org/2006/10/ttaf1",
+		"http://www.w3.org/2006/10/ttaf1#styling",
+		"http://www.w3.org/2006/10/ttaf1#metadata",
+		"http://www.w3.org/2006/10/ttaf1#parameter",
+	},
+}
+
+// xmlText escapes text for use in XML content and attribute values
+func xmlText(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// formatTTML renders segments as TTML or DFXP captions: white text on black
+// in a region across the bottom of the picture, keeping the segments' line
+// breaks. Speakers become ttm:agent metadata and
+// segments in other languages carry their own xml:lang.
+func formatTTML(t *Transcript, format string) string {
+	ns := ttmlNamespaces[format]
+	language := t.Language
+	if language == "" {
+		language = "und"
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, `<tt xmlns="%s" xmlns:tts="%s" xmlns:ttm="%s" xmlns:ttp="%s" ttp:timeBase="media" xml:lang="%s">`+"\n",
+		ns[0], ns[1], ns[2], ns[3], xmlText(language))
+
+	b.WriteString("  <head>\n    <metadata>\n")
+	if t.Source != "" {
+		fmt.Fprintf(&b, "      <ttm:title>%s</ttm:title>\n", xmlText(filepath.Base(t.Source)))
+	}
+	if t.Partial {
+		fmt.Fprintf(&b, "      <ttm:desc>%s</ttm:desc>\n", xmlText(partialNote(t)))
+	}
+	agents := map[string]string{}
+	for i, speaker := range t.speakers() {
+		agents[speaker] = fmt.Sprintf("speaker%d", i+1)
+		fmt.Fprintf(&b, "      <ttm:agent xml:id=\"%s\" type=\"person\"><ttm:name type=\"full\">%s</ttm:name></ttm:agent>\n",
+			agents[speaker], xmlText(speaker))
+	}
+	b.WriteString("    </metadata>\n")
+	b.WriteString(`    <styling>
+      <style xml:id="caption" tts:fontFamily="proportionalSansSerif" tts:fontSize="100%" tts:lineHeight="125%" tts:color="white" tts:backgroundColor="black" tts:textAlign="center"/>
+      <style xml:id="speaker" tts:color="yellow"/>
+    </styling>
+    <layout>
+      <region xml:id="bottom" tts:origin="10% 80%" tts:extent="80% 15%" tts:displayAlign="after"/>
+    </layout>
+  </head>
+`)
+
+	b.WriteString("  <body region=\"bottom\" style=\"caption\">\n    <div>\n")
+	for _, seg := range t.Segments {
+		attrs := fmt.Sprintf(`begin="%s" end="%s"`, formatTimestamp(seg.Start, "."), formatTimestamp(seg.End, "."))
+		if seg.foreign(t) {
+			attrs += fmt.Sprintf(` xml:lang="%s"`, xmlText(seg.Language))
+		}
+
+		var text string
+		if seg.Speaker != "" {
+			attrs += fmt.Sprintf(` ttm:agent="%s"`, agents[seg.Speaker])
+			text = `<span style="speaker">` + xmlText(seg.Speaker+":") + "</span> "
+		}
+		var lines []string
+		for _, line := range strings.Split(strings.TrimSpace(seg.Text), "\n") {
+			lines = append(lines, xmlText(line))
+		}
+		text += strings.Join(lines, "<br/>")
+
+		fmt.Fprintf(&b, "      <p %s>%s</p>\n", attrs, text)
+	}
+	b.WriteString("    </div>\n  </body>\n</tt>\n")
+	return b.String()
+}
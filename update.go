@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// version is the release this binary was built from, set with
+// -ldflags "-X main.version=v1.2.3". Development builds never update.
+var version = "dev"
+
+// releaseSigningKey is the base64 ed25519 public key release checksums are
+// signed with, set with -ldflags. When empty only checksums are verified.
+var releaseSigningKey = ""
+
+const releasesURL = "https://api.github.com/repos/andyanalog/speech-to-text-cli/releases/latest"
+
+// release is the part of the GitHub releases API response we use
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// assetURL returns the download URL of the named release asset
+func (r release) assetURL(name string) (string, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.URL, true
+		}
+	}
+	return "", false
+}
+
+// releaseAssetName is the binary published for the current platform
+func releaseAssetName() string {
+	name := fmt.Sprintf("stt-cli_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// fetchLatestRelease asks GitHub for the newest release
+func fetchLatestRelease(timeout time.Duration) (release, error) {
+	var r release
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(releasesURL)
+	if err != nil {
+		return r, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return r, fmt.Errorf("failed to check for updates: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return r, fmt.Errorf("failed to parse release information: %w", err)
+	}
+	return r, nil
+}
+
+// newerVersion reports whether version a is newer than b, comparing the
+// numeric parts of tags such as v1.4.2
+func newerVersion(a, b string) bool {
+	partsA := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	partsB := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < max(len(partsA), len(partsB)); i++ {
+		var x, y int
+		if i < len(partsA) {
+			x, _ = strconv.Atoi(strings.SplitN(partsA[i], "-", 2)[0])
+		}
+		if i < len(partsB) {
+			y, _ = strconv.Atoi(strings.SplitN(partsB[i], "-", 2)[0])
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+// download fetches a URL into memory
+func download(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// expectedChecksum finds the SHA-256 of name in a sha256sum-style file
+func expectedChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum published for %s", name)
+}
+
+// verifySignature checks the checksums file against the release signing key
+func verifySignature(checksums, signature []byte) error {
+	key, err := base64.StdEncoding.DecodeString(releaseSigningKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release signing key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("invalid checksum signature: %w", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), checksums, sig) {
+		return fmt.Errorf("checksum signature does not match")
+	}
+	return nil
+}
+
+// replaceExecutable swaps the running binary for data. Windows can't
+// overwrite a running executable, but it can rename it out of the way.
+func replaceExecutable(data []byte) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+
+	newPath := exe + ".new"
+	if err := os.WriteFile(newPath, data, 0755); err != nil {
+		return fmt.Errorf("failed to write update: %w", err)
+	}
+
+	oldPath := ""
+	if runtime.GOOS == "windows" {
+		oldPath = exe + ".old"
+		os.Remove(oldPath)
+		if err := os.Rename(exe, oldPath); err != nil {
+			os.Remove(newPath)
+			return fmt.Errorf("failed to replace %s: %w", exe, err)
+		}
+	}
+	if err := os.Rename(newPath, exe); err != nil {
+		os.Remove(newPath)
+		// Put the old binary back rather than leave none
+		if oldPath != "" {
+			os.Rename(oldPath, exe)
+		}
+		return fmt.Errorf("failed to replace %s: %w", exe, err)
+	}
+	return nil
+}
+
+// runSelfUpdate downloads the latest release for this platform, verifies
+// it and replaces the running binary
+func runSelfUpdate(args []string) error {
+	fs := flag.NewFlagSet("self-update", flag.ContinueOnError)
+	checkOnly := fs.Bool("check", false, "only report whether an update is available")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if version == "dev" {
+		return fmt.Errorf("development builds don't update; install a release or rebuild from source")
+	}
+
+	latest, err := fetchLatestRelease(30 * time.Second)
+	if err != nil {
+		return err
+	}
+	if !newerVersion(latest.TagName, version) {
+		fmt.Printf("stt-cli %s is up to date\n", version)
+		return nil
+	}
+	fmt.Printf("Update available: %s -> %s\n", version, latest.TagName)
+	if *checkOnly {
+		return nil
+	}
+
+	name := releaseAssetName()
+	binaryURL, ok := latest.assetURL(name)
+	if !ok {
+		return fmt.Errorf("release %s has no binary for %s/%s", latest.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	checksumsURL, ok := latest.assetURL("checksums.txt")
+	if !ok {
+		return fmt.Errorf("release %s has no checksums.txt; refusing to install unverified binary", latest.TagName)
+	}
+
+	checksums, err := download(checksumsURL)
+	if err != nil {
+		return err
+	}
+	if releaseSigningKey != "" {
+		signatureURL, ok := latest.assetURL("checksums.txt.sig")
+		if !ok {
+			return fmt.Errorf("release %s is not signed", latest.TagName)
+		}
+		signature, err := download(signatureURL)
+		if err != nil {
+			return err
+		}
+		if err := verifySignature(checksums, signature); err != nil {
+			return err
+		}
+	}
+	want, err := expectedChecksum(checksums, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Downloading %s\n", name)
+	binary, err := download(binaryURL)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, want, got)
+	}
+
+	if err := replaceExecutable(binary); err != nil {
+		return err
+	}
+	fmt.Printf("Updated to %s\n", latest.TagName)
+	return nil
+}
+
+// updateAvailableMsg reports a newer release to the TUI
+type updateAvailableMsg struct {
+	version string
+}
+
+// checkUpdateCmd looks for a newer release in the background. It stays
+// silent on errors and is skipped for development builds or when disabled.
+func checkUpdateCmd(cfg Config) tea.Cmd {
+	if version == "dev" || cfg.DisableUpdateCheck || os.Getenv("STT_NO_UPDATE_CHECK") != "" {
+		return nil
+	}
+	return func() tea.Msg {
+		latest, err := fetchLatestRelease(5 * time.Second)
+		if err != nil || !newerVersion(latest.TagName, version) {
+			return nil
+		}
+		return updateAvailableMsg{version: latest.TagName}
+	}
+}
@@ -1,102 +1,304 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // AudioProcessor handles the speech-to-text pipeline
 type AudioProcessor struct {
-	InputPath  string
-	TempDir    string
-	FFmpegPath string
-	PythonPath string
+	InputPath   string
+	TempDir     string
+	FFmpegPath  string
+	FFprobePath string
+	PythonPath  string
+	Media       *MediaInfo
+	Config      Config
+	Backend     Backend
+	Hooks       PipelineHooks
+	Stats       RunStats
+}
+
+// ProgressFunc receives a short description of each pipeline stage as it starts
+type ProgressFunc func(stage string)
+
+// SegmentFunc receives each segment as soon as the backend produces it
+type SegmentFunc func(seg Segment)
+
+// MediaFunc inspects the probed input before it is transcribed; info is nil
+// when ffprobe is unavailable. Returning an error stops the run.
+type MediaFunc func(info *MediaInfo) error
+
+// PipelineHooks are optional callbacks for following a running pipeline
+type PipelineHooks struct {
+	Progress ProgressFunc
+	Segment  SegmentFunc
+	Media    MediaFunc
+
+	// MaxDownload stops downloads of remote inputs at this many bytes, as
+	// the server limits uploads; 0 for no limit
+	MaxDownload int64
+}
+
+// RunStats describes the resources a transcription used
+type RunStats struct {
+	AudioDuration float64 // seconds of input audio
+	Transcribing  time.Duration
+	PeakMemory    uint64 // peak resident memory of the backend in bytes, 0 if unknown
+}
+
+// StageError records which pipeline stage failed
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string { return e.Err.Error() }
+func (e *StageError) Unwrap() error { return e.Err }
+
+// stageFailure wraps an error from a pipeline stage, reporting cancellation
+// and timeouts as such rather than as the tool's failure they caused
+func stageFailure(ctx context.Context, stage string, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return &StageError{"timeout", fmt.Errorf("timed out while in %s stage: %w", stage, ctx.Err())}
+	case ctx.Err() != nil:
+		return &StageError{"cancelled", fmt.Errorf("cancelled during %s stage: %w", stage, ctx.Err())}
+	}
+	return &StageError{stage, err}
 }
 
 // processAudioSTT orchestrates the speech-to-text process
-func processAudioSTT(inputPath string) (string, error) {
+func processAudioSTT(inputPath string, cfg Config) (*Transcript, error) {
+	return processAudioSTTWithProgress(inputPath, cfg, nil)
+}
+
+// processAudioSTTWithProgress runs the pipeline, reporting each stage to progress
+func processAudioSTTWithProgress(inputPath string, cfg Config, progress ProgressFunc) (*Transcript, error) {
+	transcript, _, err := runPipeline(context.Background(), inputPath, cfg, PipelineHooks{Progress: progress})
+	return transcript, err
+}
+
+// runPipeline runs the pipeline with the given hooks and also reports how
+// long the transcription took and how much memory it used. Cancelling ctx
+// stops the external tools.
+func runPipeline(ctx context.Context, inputPath string, cfg Config, hooks PipelineHooks) (*Transcript, RunStats, error) {
+	var stats RunStats
+	cfg = cfg.withLowMemory()
+	backend, err := pipelineBackend(cfg.Backend)
+	if err != nil {
+		return nil, stats, err
+	}
+
+	// Each run gets its own temp directory so jobs can run side by side
+	tempDir, err := os.MkdirTemp("", "audio_stt-")
+	if err != nil {
+		return nil, stats, stageFailure(ctx, "setup", fmt.Errorf("failed to create temp directory: %w", err))
+	}
+	defer os.RemoveAll(tempDir)
+
 	processor := &AudioProcessor{
 		InputPath: inputPath,
-		TempDir:   filepath.Join(os.TempDir(), "audio_stt"),
+		TempDir:   tempDir,
+		Config:    cfg,
+		Backend:   backend,
+		Hooks:     hooks,
 	}
 
-	// Create temp directory
-	if err := os.MkdirAll(processor.TempDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	audioPath, err := processor.prepare(ctx)
+	if err != nil {
+		return nil, stats, err
 	}
-	defer os.RemoveAll(processor.TempDir)
 
+	// Transcribe audio
+	processor.report(fmt.Sprintf("Transcribing with %s (%s model)", processor.Backend.Name, cfg.Model))
+	transcript, err := processor.transcribeAudio(ctx, audioPath)
+	if err != nil {
+		return nil, processor.Stats, stageFailure(ctx, "transcription", fmt.Errorf("transcription failed: %w", err))
+	}
+
+	if cfg.CodeSwitching {
+		processor.report("Detecting the language of each segment")
+		if err := processor.tagLanguages(ctx, audioPath, transcript); err != nil {
+			return nil, processor.Stats, stageFailure(ctx, "language", fmt.Errorf("language detection failed: %w", err))
+		}
+	}
+	if cfg.Diarize {
+		processor.report("Identifying speakers")
+		if err := processor.diarize(ctx, audioPath, transcript); err != nil {
+			return nil, processor.Stats, stageFailure(ctx, "diarization", fmt.Errorf("diarization failed: %w", err))
+		}
+	}
+	applyTextFormatting(transcript, cfg)
+
+	// Corrections come last so they have the final say over the text
+	if cfg.Corrections != "" {
+		rules, err := loadCorrections(cfg.Corrections)
+		if err != nil {
+			return nil, processor.Stats, stageFailure(ctx, "corrections", err)
+		}
+		applyCorrections(transcript, rules)
+	}
+	if cfg.Analyze {
+		analyzeTranscript(transcript, cfg)
+	}
+
+	// Without ffprobe, the end of the last segment is the best estimate
+	processor.Stats.AudioDuration = transcript.duration()
+	if processor.Media != nil && processor.Media.Duration > 0 {
+		processor.Stats.AudioDuration = processor.Media.Duration
+	}
+
+	transcript.Source = inputPath
+	transcript.Model = cfg.Model
+	transcript.Backend = backend.Name
+	transcript.Duration = processor.Stats.AudioDuration
+
+	return transcript, processor.Stats, nil
+}
+
+// prepare checks dependencies, fetches and validates the input and extracts
+// its audio into the temp directory, returning the path of the WAV file
+func (p *AudioProcessor) prepare(ctx context.Context) (string, error) {
 	// Check dependencies
-	if err := processor.checkDependencies(); err != nil {
-		return "", fmt.Errorf("dependency check failed: %w", err)
+	p.report("Checking dependencies")
+	if err := p.checkDependencies(ctx); err != nil {
+		return "", stageFailure(ctx, "dependencies", fmt.Errorf("dependency check failed: %w", err))
+	}
+
+	// Download remote inputs into the temp directory first
+	if input := p.InputPath; isRemoteInput(input) {
+		p.report("Downloading " + input)
+		localPath, err := fetchRemoteInput(ctx, input, p.TempDir, p.Hooks.MaxDownload)
+		if err != nil {
+			return "", stageFailure(ctx, "download", fmt.Errorf("failed to fetch %s: %w", input, err))
+		}
+		p.InputPath = localPath
+	}
+
+	// Make sure the input actually contains audio ffmpeg can decode
+	if err := p.validateInput(); err != nil {
+		return "", stageFailure(ctx, "input", fmt.Errorf("unsupported input: %w", err))
+	}
+	if p.Hooks.Media != nil {
+		if err := p.Hooks.Media(p.Media); err != nil {
+			return "", stageFailure(ctx, "input", err)
+		}
 	}
 
 	// Extract audio from video/audio file
-	audioPath := filepath.Join(processor.TempDir, "audio.wav")
-	if err := processor.extractAudio(audioPath); err != nil {
-		return "", fmt.Errorf("audio extraction failed: %w", err)
+	p.report("Extracting audio")
+	audioPath := filepath.Join(p.TempDir, "audio.wav")
+	if err := p.extractAudio(ctx, audioPath); err != nil {
+		return "", stageFailure(ctx, "extraction", fmt.Errorf("audio extraction failed: %w", err))
 	}
+	return audioPath, nil
+}
 
-	// Transcribe audio
-	transcription, err := processor.transcribeAudio(audioPath)
-	if err != nil {
-		return "", fmt.Errorf("transcription failed: %w", err)
+// report passes a stage description to the progress callback, if any
+func (p *AudioProcessor) report(stage string) {
+	if p.Hooks.Progress != nil {
+		p.Hooks.Progress(stage)
 	}
+}
 
-	return transcription, nil
+// findFFmpeg locates ffmpeg in PATH or in common Windows install locations
+func findFFmpeg() (string, error) {
+	if path, err := exec.LookPath("ffmpeg"); err == nil {
+		return path, nil
+	}
+
+	// Try common Windows locations for ffmpeg
+	commonPaths := []string{
+		"C:\\ffmpeg\\bin\\ffmpeg.exe",
+		"C:\\Program Files\\ffmpeg\\bin\\ffmpeg.exe",
+		".\\ffmpeg.exe",
+	}
+	for _, commonPath := range commonPaths {
+		if _, err := os.Stat(commonPath); err == nil {
+			return commonPath, nil
+		}
+	}
+
+	return "", fmt.Errorf("ffmpeg not found. Please install FFmpeg or place ffmpeg.exe in the current directory")
 }
 
 // checkDependencies verifies required tools are available
-func (p *AudioProcessor) checkDependencies() error {
-	dependencies := map[string]*string{
-		"ffmpeg": &p.FFmpegPath,
-		"python": &p.PythonPath,
+func (p *AudioProcessor) checkDependencies(ctx context.Context) error {
+	ffmpegPath, err := findFFmpeg()
+	if err != nil {
+		return err
 	}
+	p.FFmpegPath = ffmpegPath
 
-	for tool, pathVar := range dependencies {
-		path, err := exec.LookPath(tool)
-		if err != nil {
-			// Try common Windows locations for ffmpeg
-			if tool == "ffmpeg" {
-				commonPaths := []string{
-					"C:\\ffmpeg\\bin\\ffmpeg.exe",
-					"C:\\Program Files\\ffmpeg\\bin\\ffmpeg.exe",
-					".\\ffmpeg.exe",
-				}
-				for _, commonPath := range commonPaths {
-					if _, err := os.Stat(commonPath); err == nil {
-						*pathVar = commonPath
-						break
-					}
-				}
-				if *pathVar == "" {
-					return fmt.Errorf("ffmpeg not found. Please install FFmpeg or place ffmpeg.exe in the current directory")
-				}
-			} else {
-				return fmt.Errorf("%s not found in PATH", tool)
-			}
-		} else {
-			*pathVar = path
-		}
+	pythonPath, err := exec.LookPath("python")
+	if err != nil {
+		return fmt.Errorf("python not found in PATH")
 	}
+	p.PythonPath = pythonPath
+
+	// ffprobe is optional; without it inputs are validated by extension
+	p.FFprobePath = findFFprobe(p.FFmpegPath)
 
-	// Install required Python packages
-	cmd := exec.Command(p.PythonPath, "-m", "pip", "install", "openai-whisper")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to install openai-whisper: %w", err)
+	// Install the backend's Python package unless it is already importable
+	if err := exec.CommandContext(ctx, p.PythonPath, "-c", "import "+p.Backend.Module).Run(); err != nil {
+		cmd := exec.CommandContext(ctx, p.PythonPath, "-m", "pip", "install", p.Backend.Package)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to install %s: %w", p.Backend.Package, err)
+		}
 	}
 
 	return nil
 }
 
+// audioFilters returns the ffmpeg filter chain for the enabled preprocessing
+// options, or an empty string when none are enabled
+func (p *AudioProcessor) audioFilters() string {
+	var filters []string
+	if p.Config.VoiceFilter {
+		filters = append(filters, "highpass=f=80", "lowpass=f=8000")
+	}
+	if p.Config.Denoise {
+		filters = append(filters, "afftdn")
+	}
+	if p.Config.Normalize {
+		filters = append(filters, "loudnorm")
+	}
+	return strings.Join(filters, ",")
+}
+
 // extractAudio extracts audio track from video/audio file using FFmpeg
-func (p *AudioProcessor) extractAudio(outputPath string) error {
-	cmd := exec.Command(p.FFmpegPath,
+func (p *AudioProcessor) extractAudio(ctx context.Context, outputPath string) error {
+	cmd := exec.CommandContext(ctx, p.FFmpegPath, p.extractArgs(outputPath)...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg error: %s", string(output))
+	}
+	return nil
+}
+
+// extractArgs returns the ffmpeg arguments that extract the input's audio
+// as 16 kHz mono WAV to outputPath
+func (p *AudioProcessor) extractArgs(outputPath string) []string {
+	args := []string{
 		"-i", p.InputPath,
 		"-vn", // no video
+	}
+	if filters := p.audioFilters(); filters != "" {
+		args = append(args, "-af", filters)
+	}
+	args = append(args,
 		"-acodec", "pcm_s16le",
 		"-ar", "16000", // 16kHz sample rate for Whisper
 		"-ac", "1", // mono
@@ -104,12 +306,7 @@ func (p *AudioProcessor) extractAudio(outputPath string) error {
 		outputPath,
 		"-y", // overwrite output file
 	)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("ffmpeg error: %s", string(output))
-	}
-	return nil
+	return args
 }
 
 // Helper function to escape paths for Python (using raw strings)
@@ -118,52 +315,81 @@ func pythonPath(path string) string {
 	return fmt.Sprintf(`r"%s"`, path)
 }
 
-// transcribeAudio uses Whisper to transcribe audio and return the text
-func (p *AudioProcessor) transcribeAudio(audioPath string) (string, error) {
-	script := fmt.Sprintf(`
-import whisper
-import os
+// transcribeAudio runs the configured backend on the audio and returns the
+// timed transcript
+func (p *AudioProcessor) transcribeAudio(ctx context.Context, audioPath string) (*Transcript, error) {
+	transcriptionPath := filepath.Join(p.TempDir, "transcription.json")
+	script := p.Backend.Script(audioPath, transcriptionPath, p.Config)
 
-print("Loading Whisper model...")
-model = whisper.load_model("base")
-print("Transcribing audio...")
-result = model.transcribe(%s)
+	scriptPath := filepath.Join(p.TempDir, "transcribe.py")
+	if err := os.WriteFile(scriptPath, []byte(script), 0644); err != nil {
+		return nil, err
+	}
 
-# Extract the full transcription text
-transcription = result["text"].strip()
-print("Transcription completed")
-print("=" * 50)
-print(transcription)
-print("=" * 50)
+	cmd := exec.CommandContext(ctx, p.PythonPath, scriptPath)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	// exec copies stderr from its own goroutine, so it gets its own buffer
+	var output, stderr bytes.Buffer
+	cmd.Stderr = &stderr
 
-# Save to a temporary file for Go to read
-temp_output = %s
-with open(temp_output, "w", encoding="utf-8") as f:
-    f.write(transcription)
-`, pythonPath(audioPath), pythonPath(filepath.Join(p.TempDir, "transcription.txt")))
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start python: %w", err)
+	}
 
-	scriptPath := filepath.Join(p.TempDir, "transcribe.py")
-	if err := os.WriteFile(scriptPath, []byte(script), 0644); err != nil {
-		return "", err
+	// Segments are streamed as tagged JSON lines; everything else is kept
+	// for the error message
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, segmentLinePrefix) {
+			var seg Segment
+			data := strings.TrimPrefix(line, segmentLinePrefix)
+			if err := json.Unmarshal([]byte(data), &seg); err == nil && p.Hooks.Segment != nil {
+				p.Hooks.Segment(seg)
+			}
+			continue
+		}
+		output.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(&output, "reading script output: %v\n", err)
 	}
+	// Python blocks on a full pipe until the rest is read
+	io.Copy(io.Discard, stdout)
 
-	cmd := exec.Command(p.PythonPath, scriptPath)
-	output, err := cmd.CombinedOutput()
+	err = cmd.Wait()
+	p.Stats.Transcribing = time.Since(start)
+	if cmd.ProcessState != nil {
+		p.Stats.PeakMemory = peakMemory(cmd.ProcessState)
+	}
 	if err != nil {
-		return "", fmt.Errorf("python transcription error: %s", string(output))
+		return nil, fmt.Errorf("python transcription error: %s", output.String()+stderr.String())
 	}
 
 	// Read the transcription from the temporary file
-	transcriptionPath := filepath.Join(p.TempDir, "transcription.txt")
 	transcriptionBytes, err := os.ReadFile(transcriptionPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read transcription file: %w", err)
+		return nil, fmt.Errorf("failed to read transcription file: %w", err)
 	}
 
-	transcription := strings.TrimSpace(string(transcriptionBytes))
-	if transcription == "" {
-		return "No speech detected in the audio file.", nil
+	var transcript Transcript
+	if err := json.Unmarshal(transcriptionBytes, &transcript); err != nil {
+		return nil, fmt.Errorf("failed to parse transcription file: %w", err)
+	}
+	transcript.Text = strings.TrimSpace(transcript.Text)
+	if transcript.Text == "" {
+		// Low-memory scripts write segments as they go, without the full text
+		texts := make([]string, len(transcript.Segments))
+		for i, seg := range transcript.Segments {
+			texts[i] = seg.Text
+		}
+		transcript.Text = strings.TrimSpace(strings.Join(texts, " "))
 	}
 
-	return transcription, nil
+	return &transcript, nil
 }